@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/yaoapp/kun/log"
 
@@ -16,8 +17,43 @@ import (
 // APIs 已加载API列表
 var APIs = map[string]*API{}
 
-// Load load the api
+// apisMutex guard the APIs map, the route table of the dynamic router is rebuilt while serving
+var apisMutex sync.RWMutex
+
+// Load load the api, the routes of the dynamic router are refreshed
 func Load(file, id string, guard ...string) (*API, error) {
+	api, err := load(file, id, guard...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hot reload
+	if err := RefreshRoutes(); err != nil {
+		log.Error("[API] Load %s Error: %s", id, err.Error())
+	}
+	return api, nil
+}
+
+// LoadFiles load the apis (id => file), the routes of the dynamic router are refreshed once after loading
+func LoadFiles(files map[string]string, guard ...string) error {
+	var res error
+	for id, file := range files {
+		if _, err := load(file, id, guard...); err != nil && res == nil {
+			res = err
+		}
+	}
+
+	if err := RefreshRoutes(); err != nil {
+		log.Error("[API] Load Error: %s", err.Error())
+		if res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// load parse the api and add it to the APIs
+func load(file, id string, guard ...string) (*API, error) {
 
 	data, err := application.App.Read(file)
 	if err != nil {
@@ -47,24 +83,37 @@ func Load(file, id string, guard ...string) (*API, error) {
 		uniquePathCheck[unique] = true
 	}
 
+	// Validate the deprecation dates
+	for _, path := range http.Paths {
+		if _, err := http.deprecation(path); err != nil {
+			log.Error("[API] Load %s Error: %s %s", id, path.Path, err.Error())
+			return nil, fmt.Errorf("[API] Load %s Error: %s %s", id, path.Path, err.Error())
+		}
+	}
+
 	// Default Guard
 	if http.Guard == "" && len(guard) > 0 {
 		http.Guard = guard[0]
 	}
 
-	APIs[id] = &API{
+	api := &API{
 		ID:   id,
 		File: file,
 		HTTP: http,
 		Type: "http",
 	}
 
-	return APIs[id], nil
+	apisMutex.Lock()
+	APIs[id] = api
+	apisMutex.Unlock()
+	return api, nil
 }
 
 // Select select api
 func Select(id string) *API {
+	apisMutex.RLock()
 	api, has := APIs[id]
+	apisMutex.RUnlock()
 	if !has {
 		exception.New("[API] %s not loaded", 500, id).Throw()
 	}
@@ -74,8 +123,29 @@ func Select(id string) *API {
 // SetRoutes set the api routes
 func SetRoutes(router *gin.Engine, path string, allows ...string) {
 
+	// Request id & access logs (before the error handler, the failed requests are logged)
+	router.Use(RequestLogger())
+
 	// Error handler
-	router.Use(gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+	router.Use(recovery())
+
+	// Load apis
+	apisMutex.RLock()
+	defer apisMutex.RUnlock()
+
+	// Select the version with the request header
+	if Versioning {
+		router.Use(versionSelect(versionRoutes(path, allows...), path))
+	}
+
+	for _, api := range APIs {
+		api.HTTP.Routes(router, path, allows...)
+	}
+}
+
+// recovery the error handler, the exception code is used as the status code
+func recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
 
 		var code = http.StatusInternalServerError
 
@@ -104,12 +174,7 @@ func SetRoutes(router *gin.Engine, path string, allows ...string) {
 		}
 
 		c.AbortWithStatus(code)
-	}))
-
-	// Load apis
-	for _, api := range APIs {
-		api.HTTP.Routes(router, path, allows...)
-	}
+	})
 }
 
 // SetGuards set guards
@@ -126,3 +191,15 @@ func AddGuard(name string, guard gin.HandlerFunc) {
 func (api *API) Reload() (*API, error) {
 	return Load(api.File, api.ID)
 }
+
+// Unload remove the api, the routes are removed if the dynamic router is used
+func Unload(id string) error {
+	apisMutex.Lock()
+	if _, has := APIs[id]; !has {
+		apisMutex.Unlock()
+		return fmt.Errorf("[API] %s not loaded", id)
+	}
+	delete(APIs, id)
+	apisMutex.Unlock()
+	return RefreshRoutes()
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
+	gouhttp "github.com/yaoapp/gou/http"
+	"github.com/yaoapp/kun/log"
+)
+
+// CacheStore the default name of the store which keeps the cached responses
+var CacheStore = "__cache"
+
+// CacheTTL the default ttl of the cached responses
+var CacheTTL = 60 * time.Second
+
+// cacheStores the names of the stores used by the paths
+var cacheStores = sync.Map{}
+
+// cacheWriter buffer the response, the ETag header is set before writing it
+type cacheWriter struct {
+	gin.ResponseWriter
+	status int
+	body   *bytes.Buffer
+}
+
+func (w *cacheWriter) WriteHeader(code int) { w.status = code }
+func (w *cacheWriter) WriteHeaderNow()      {}
+func (w *cacheWriter) Status() int          { return w.status }
+func (w *cacheWriter) Size() int            { return w.body.Len() }
+func (w *cacheWriter) Written() bool        { return w.body.Len() > 0 }
+
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *cacheWriter) WriteString(data string) (int, error) {
+	return w.body.WriteString(data)
+}
+
+// cache cache the response of the GET path, answer If-None-Match with 304
+func (http HTTP) cache(path Path) gin.HandlerFunc {
+
+	option := path.Cache
+	name := option.Store
+	if name == "" {
+		name = CacheStore
+	}
+	cacheStores.Store(name, true)
+
+	ttl := CacheTTL
+	if option.TTL > 0 {
+		ttl = time.Duration(option.TTL) * time.Second
+	}
+
+	vary := append([]string{}, option.Vary...)
+	if len(vary) == 0 {
+		vary = []string{"query", "param"}
+	}
+
+	// the responses are private by default, the processes could read the session
+	control := fmt.Sprintf("public, max-age=%d", int(ttl.Seconds()))
+	if !option.Public {
+		control = fmt.Sprintf("private, max-age=%d", int(ttl.Seconds()))
+		if !cacheVaried(vary, "session") {
+			vary = append(vary, "session")
+		}
+	}
+
+	return func(c *gin.Context) {
+
+		if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+			c.Next()
+			return
+		}
+
+		stor, err := useStore(name)
+		if err != nil {
+			log.Error("[API] %s %s cache: %s", c.Request.Method, c.FullPath(), err.Error())
+			c.Next()
+			return
+		}
+		id := cacheKey(c, vary)
+
+		// Cache hit
+		if value, ok := stor.Get(id); ok {
+			res := storedResponse{}
+			if text, ok := value.(string); ok && jsoniter.UnmarshalFromString(text, &res) == nil {
+				for name, value := range res.Headers {
+					c.Writer.Header().Set(name, value)
+				}
+				c.Writer.Header().Set("X-Cache", "HIT")
+				if cacheMatch(c.GetHeader("If-None-Match"), res.Headers["Etag"]) {
+					c.AbortWithStatus(304)
+					return
+				}
+				c.Data(res.Status, c.Writer.Header().Get("Content-Type"), res.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		writer := &cacheWriter{ResponseWriter: c.Writer, status: 200, body: &bytes.Buffer{}}
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		if writer.status != 200 {
+			c.Writer.WriteHeader(writer.status)
+			c.Writer.WriteHeaderNow()
+			c.Writer.Write(writer.body.Bytes())
+			return
+		}
+
+		sum := sha1.Sum(writer.body.Bytes())
+		etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:]))
+		c.Writer.Header().Set("Etag", etag)
+		c.Writer.Header().Set("Cache-Control", control)
+
+		res := storedResponse{Status: writer.status, Headers: map[string]string{}, Body: writer.body.Bytes()}
+		for name, values := range c.Writer.Header() {
+			if len(values) > 0 && !strings.HasPrefix(name, "Access-Control-") && name != "Set-Cookie" && !strings.EqualFold(name, gouhttp.RequestIDHeader) {
+				res.Headers[name] = values[0]
+			}
+		}
+
+		text, err := jsoniter.MarshalToString(res)
+		if err == nil {
+			err = stor.Set(id, text, ttl)
+		}
+		if err != nil {
+			log.Error("[API] %s %s cache: %s", c.Request.Method, c.FullPath(), err.Error())
+		}
+
+		c.Writer.Header().Set("X-Cache", "MISS")
+		if cacheMatch(c.GetHeader("If-None-Match"), etag) {
+			c.Writer.WriteHeader(304)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.Write(writer.body.Bytes())
+	}
+}
+
+// ClearCache remove the cached responses of the route (eg: /api/user/pets/:id), remove all if the route is not given
+func ClearCache(route ...string) int {
+	prefix := "cache:"
+	if len(route) > 0 && route[0] != "" {
+		prefix = fmt.Sprintf("cache:%s#", route[0])
+	}
+
+	cnt := 0
+	cacheStores.Range(func(name, _ interface{}) bool {
+		stor, err := useStore(name.(string))
+		if err != nil {
+			log.Error("[API] clear cache %s: %s", name, err.Error())
+			return true
+		}
+
+		keys := []string{}
+		for _, key := range stor.Keys() {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		stor.DelMulti(keys)
+		cnt = cnt + len(keys)
+		return true
+	})
+	return cnt
+}
+
+// cacheKey the key of the response. cache:<route>#<method>#<hash of the vary values>
+func cacheKey(c *gin.Context, vary []string) string {
+	values := []string{}
+	for _, v := range vary {
+		switch {
+		case v == "query":
+			values = append(values, "query="+c.Request.URL.Query().Encode())
+
+		case v == "param":
+			params := []string{}
+			for _, param := range c.Params {
+				params = append(params, param.Key+"="+param.Value)
+			}
+			sort.Strings(params)
+			values = append(values, "param="+strings.Join(params, "&"))
+
+		case v == "session":
+			values = append(values, "session="+c.GetString("__sid"))
+
+		case strings.HasPrefix(v, "query."):
+			values = append(values, v+"="+c.Query(strings.TrimPrefix(v, "query.")))
+
+		case strings.HasPrefix(v, "param."):
+			values = append(values, v+"="+c.Param(strings.TrimPrefix(v, "param.")))
+
+		case strings.HasPrefix(v, "header."):
+			values = append(values, v+"="+c.GetHeader(strings.TrimPrefix(v, "header.")))
+		}
+	}
+
+	sum := sha1.Sum([]byte(strings.Join(values, "\n")))
+	return fmt.Sprintf("cache:%s#%s#%s", c.FullPath(), c.Request.Method, hex.EncodeToString(sum[:]))
+}
+
+// cacheVaried check if the value is varied
+func cacheVaried(vary []string, name string) bool {
+	for _, v := range vary {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheMatch check the If-None-Match header
+func cacheMatch(header string, etag string) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == etag {
+			return true
+		}
+	}
+	return false
+}
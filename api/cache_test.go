@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+)
+
+func TestCacheETag(t *testing.T) {
+	var count int32 = 0
+	process.Register("tests.cache.pet", func(p *process.Process) interface{} {
+		return map[string]interface{}{"id": p.Args[0], "count": atomic.AddInt32(&count, 1)}
+	})
+	defer ClearCache()
+
+	router := cacheRouter(true)
+	res := cacheRequest(router, "/cache/pets/1", "")
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, "MISS", res.Header().Get("X-Cache"))
+	assert.Equal(t, "public, max-age=30", res.Header().Get("Cache-Control"))
+	assert.Equal(t, `{"count":1,"id":"1"}`, res.Body.String())
+	etag := res.Header().Get("Etag")
+	assert.NotEmpty(t, etag)
+
+	res = cacheRequest(router, "/cache/pets/1", "")
+	assert.Equal(t, "HIT", res.Header().Get("X-Cache"))
+	assert.Equal(t, `{"count":1,"id":"1"}`, res.Body.String())
+
+	res = cacheRequest(router, "/cache/pets/1", etag)
+	assert.Equal(t, 304, res.Code)
+	assert.Empty(t, res.Body.String())
+
+	res = cacheRequest(router, "/cache/pets/2", "")
+	assert.Equal(t, `{"count":2,"id":"2"}`, res.Body.String())
+
+	// Invalidate the cached responses
+	assert.Equal(t, 2, process.New("api.cache.Clear", "/cache/pets/:id").Run())
+	res = cacheRequest(router, "/cache/pets/1", etag)
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `{"count":3,"id":"1"}`, res.Body.String())
+}
+
+func TestCacheSession(t *testing.T) {
+	var count int32 = 0
+	process.Register("tests.cache.pet", func(p *process.Process) interface{} {
+		return map[string]interface{}{"id": p.Args[0], "count": atomic.AddInt32(&count, 1)}
+	})
+	defer ClearCache()
+
+	// the responses are cached per session by default
+	router := cacheRouter(false)
+	res := cacheRequest(router, "/cache/pets/1", "", "foo")
+	assert.Equal(t, "MISS", res.Header().Get("X-Cache"))
+	assert.Equal(t, "private, max-age=30", res.Header().Get("Cache-Control"))
+	assert.Equal(t, `{"count":1,"id":"1"}`, res.Body.String())
+
+	res = cacheRequest(router, "/cache/pets/1", "", "bar")
+	assert.Equal(t, "MISS", res.Header().Get("X-Cache"))
+	assert.Equal(t, `{"count":2,"id":"1"}`, res.Body.String())
+
+	res = cacheRequest(router, "/cache/pets/1", "", "foo")
+	assert.Equal(t, "HIT", res.Header().Get("X-Cache"))
+	assert.Equal(t, `{"count":1,"id":"1"}`, res.Body.String())
+}
+
+func TestCacheMatch(t *testing.T) {
+	assert.True(t, cacheMatch(`"a", W/"b"`, `"b"`))
+	assert.True(t, cacheMatch(`*`, `"b"`))
+	assert.False(t, cacheMatch(`"a"`, `"b"`))
+	assert.False(t, cacheMatch(``, `"b"`))
+}
+
+func cacheRouter(public bool) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("__sid", c.GetHeader("X-Sid")) })
+	api := HTTP{
+		Group: "cache",
+		Paths: []Path{{
+			Path: "/pets/:id", Method: "GET", Guard: "-", Process: "tests.cache.pet",
+			In: []interface{}{"$param.id"}, Out: Out{Status: 200, Type: "application/json"},
+			Cache: &Cache{TTL: 30, Store: "__tests_cache", Public: public},
+		}},
+	}
+	api.Routes(router, "/")
+	return router
+}
+
+func cacheRequest(router *gin.Engine, path string, etag string, sid ...string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	if len(sid) > 0 {
+		req.Header.Set("X-Sid", sid[0])
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	router.ServeHTTP(response, req)
+	return response
+}
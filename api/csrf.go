@@ -0,0 +1,78 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yaoapp/gou/session"
+)
+
+// CSRFHeader the header name of the csrf token
+var CSRFHeader = "X-CSRF-Token"
+
+// CSRFField the form field name of the csrf token
+var CSRFField = "_csrf"
+
+// CSRFSessionKey the session key of the csrf token
+var CSRFSessionKey = "__csrf_token"
+
+// builtinGuards the built-in guards, could be overwritten by HTTPGuards
+var builtinGuards = map[string]gin.HandlerFunc{
+	"csrf": CSRFGuard,
+}
+
+// CSRFToken get the csrf token of the session, create a new one if it does not exist (synchronizer token pattern)
+func CSRFToken(sid string) (string, error) {
+	if sid == "" {
+		return "", fmt.Errorf("the session id is required")
+	}
+
+	ss := session.Global().ID(sid)
+	value, err := ss.Get(CSRFSessionKey)
+	if err != nil {
+		return "", err
+	}
+
+	if token, ok := value.(string); ok && token != "" {
+		return token, nil
+	}
+
+	token := session.ID()
+	err = ss.Set(CSRFSessionKey, token)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CSRFGuard reject the state-changing requests without a valid csrf token.
+// The session id should be set by the previous guard, eg: "bearer-jwt,csrf"
+// The form field is read from the url-encoded forms only, the multipart requests (uploads) should take the header,
+// the multipart body is streamed by the handler and should not be parsed by the guard.
+func CSRFGuard(c *gin.Context) {
+	switch c.Request.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return
+	}
+
+	token := c.GetHeader(CSRFHeader)
+	if token == "" && c.ContentType() == "application/x-www-form-urlencoded" {
+		token = c.PostForm(CSRFField)
+	}
+
+	sid := c.GetString("__sid")
+	if token == "" || sid == "" {
+		c.JSON(403, gin.H{"code": 403, "message": "invalid csrf token"})
+		c.Abort()
+		return
+	}
+
+	value, err := session.Global().ID(sid).Get(CSRFSessionKey)
+	expected, ok := value.(string)
+	if err != nil || !ok || expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		c.JSON(403, gin.H{"code": 403, "message": "invalid csrf token"})
+		c.Abort()
+		return
+	}
+}
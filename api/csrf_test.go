@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/gou/session"
+)
+
+func TestCSRFGuard(t *testing.T) {
+	process.Register("tests.csrf.save", func(p *process.Process) interface{} {
+		return map[string]interface{}{"saved": true}
+	})
+
+	AddGuard("tests-sid", func(c *gin.Context) { c.Set("__sid", c.GetHeader("X-Sid")) })
+	defer delete(HTTPGuards, "tests-sid")
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	api := HTTP{
+		Group: "csrf",
+		Guard: "tests-sid,csrf",
+		Paths: []Path{
+			{Path: "/save", Method: "POST", Process: "tests.csrf.save", Out: Out{Status: 200, Type: "application/json"}},
+			{Path: "/save", Method: "GET", Process: "tests.csrf.save", Out: Out{Status: 200, Type: "application/json"}},
+		},
+	}
+	api.Routes(router, "/")
+
+	sid := session.ID()
+	token := process.New("api.csrf.Token").WithSID(sid).Run()
+	assert.NotEmpty(t, token)
+	assert.Equal(t, token, process.New("api.csrf.Token", sid).Run())
+
+	assert.Equal(t, 200, csrfRequest(router, "GET", sid, "").Code)
+	assert.Equal(t, 403, csrfRequest(router, "POST", sid, "").Code)
+	assert.Equal(t, 403, csrfRequest(router, "POST", sid, "invalid").Code)
+	assert.Equal(t, 403, csrfRequest(router, "POST", "", token.(string)).Code)
+	assert.Equal(t, 403, csrfRequest(router, "POST", session.ID(), token.(string)).Code)
+
+	res := csrfRequest(router, "POST", sid, token.(string))
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, true, responseMap(res).Get("saved"))
+
+	// the form field of the url-encoded forms
+	assert.Equal(t, 200, csrfForm(router, sid, "application/x-www-form-urlencoded", CSRFField+"="+token.(string)).Code)
+
+	// the multipart body is not parsed, the header is required
+	body := "--boundary\r\nContent-Disposition: form-data; name=\"" + CSRFField + "\"\r\n\r\n" + token.(string) + "\r\n--boundary--\r\n"
+	assert.Equal(t, 403, csrfForm(router, sid, "multipart/form-data; boundary=boundary", body).Code)
+}
+
+func csrfForm(router *gin.Engine, sid string, contentType string, body string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/csrf/save", strings.NewReader(body))
+	req.Header.Set("X-Sid", sid)
+	req.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(response, req)
+	return response
+}
+
+func csrfRequest(router *gin.Engine, method string, sid string, token string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, "/csrf/save", nil)
+	req.Header.Set("X-Sid", sid)
+	if token != "" {
+		req.Header.Set(CSRFHeader, token)
+	}
+	router.ServeHTTP(response, req)
+	return response
+}
@@ -0,0 +1,497 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/yaoapp/gou/model"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/log"
+)
+
+type graphQLContextKey string
+
+const (
+	graphQLSid       graphQLContextKey = "__sid"
+	graphQLGlobal    graphQLContextKey = "__global"
+	graphQLRequestID graphQLContextKey = "__request_id"
+)
+
+// GraphQLJSON the JSON scalar, used for the json columns and the query/mutation inputs
+var GraphQLJSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "The JSON scalar type represents any JSON value",
+	Serialize:   func(value interface{}) interface{} { return value },
+	ParseValue:  func(value interface{}) interface{} { return value },
+	ParseLiteral: func(value ast.Value) interface{} {
+		return graphQLLiteral(value)
+	},
+})
+
+// GraphQLGuard the default guard of the GraphQL endpoint, the mutations write the models.
+// Set the guard option to "-" to serve the endpoint without guards.
+var GraphQLGuard = "bearer-jwt"
+
+// SetGraphQL bind the auto-generated GraphQL endpoint of the loaded models to the router
+func SetGraphQL(router *gin.Engine, option GraphQL) error {
+
+	cache := &graphQLSchemaCache{}
+	if _, err := cache.get(); err != nil {
+		log.Error("[GraphQL] %s", err.Error())
+		return err
+	}
+
+	if option.Path == "" {
+		option.Path = "/graphql"
+	}
+
+	handlers := []gin.HandlerFunc{}
+	HTTP{}.guard(&handlers, option.Guard, GraphQLGuard)
+	handlers = append(handlers, func(c *gin.Context) {
+		req := GraphQLRequest{}
+		if c.Request.Method == "GET" {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+		} else if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"code": 400, "message": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// the models could be loaded or reloaded after the endpoint is bound
+		schema, err := cache.get()
+		if err != nil {
+			log.Error("[GraphQL] %s", err.Error())
+			c.JSON(500, gin.H{"code": 500, "message": err.Error()})
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		if sid, has := c.Get("__sid"); has {
+			ctx = context.WithValue(ctx, graphQLSid, sid)
+		}
+		if global, has := c.Get("__global"); has {
+			ctx = context.WithValue(ctx, graphQLGlobal, global)
+		}
+		if id := c.GetString("__request_id"); id != "" {
+			ctx = context.WithValue(ctx, graphQLRequestID, id)
+		}
+
+		res := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        ctx,
+		})
+		c.JSON(200, res)
+		c.Done()
+	})
+
+	router.GET(option.Path, handlers...)
+	router.POST(option.Path, handlers...)
+	return nil
+}
+
+// graphQLSchemaCache the schema of the loaded models, it is rebuilt when the models are changed
+type graphQLSchemaCache struct {
+	mutex     sync.Mutex
+	signature string
+	schema    graphql.Schema
+}
+
+// get return the schema, rebuild it if the loaded models are changed
+func (cache *graphQLSchemaCache) get() (graphql.Schema, error) {
+	models := model.Loaded()
+	signature := graphQLSignature(models)
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.signature != "" && cache.signature == signature {
+		return cache.schema, nil
+	}
+
+	schema, err := graphQLSchema(models)
+	if err != nil {
+		return schema, err
+	}
+	cache.schema = schema
+	cache.signature = signature
+	return schema, nil
+}
+
+// graphQLSignature the ids and the instances of the loaded models, a reloaded model is a new instance
+func graphQLSignature(models map[string]*model.Model) string {
+	ids := []string{}
+	for id, mod := range models {
+		ids = append(ids, fmt.Sprintf("%s:%p", id, mod))
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// GraphQLSchema build the GraphQL schema of the loaded models,
+// the models of which the names are used by the other models are skipped.
+func GraphQLSchema() (graphql.Schema, error) {
+	return graphQLSchema(model.Loaded())
+}
+
+// graphQLSchema build the GraphQL schema of the snapshot of the loaded models
+func graphQLSchema(models map[string]*model.Model) (graphql.Schema, error) {
+
+	ids := []string{}
+	for id := range models {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// the built-in types are reserved
+	types := map[string]string{"Query": "", "Mutation": "", "JSON": "", "Int": "", "Float": "", "String": "", "Boolean": "", "ID": ""}
+	names := map[string]string{}
+	objects := map[string]*graphql.Object{}
+	for _, id := range ids {
+		name, typ := graphQLName(id), graphQLTypeName(id)
+		used := []string{name, name + "_list", typ}
+		if graphQLUsed(id, used, names, types) {
+			continue
+		}
+		names[name], names[name+"_list"], types[typ] = id, id, id
+		objects[id] = graphQLObject(models[id], objects)
+	}
+
+	queries := graphql.Fields{}
+	mutations := graphql.Fields{}
+	for _, id := range ids {
+		object, has := objects[id]
+		if !has {
+			continue
+		}
+
+		mod := models[id]
+		name := graphQLName(id)
+		queries[name] = graphQLFind(mod, object)
+		queries[name+"_list"] = graphQLGet(mod, object)
+		mutations["create_"+name] = graphQLMutation(mod, "Create")
+		mutations["save_"+name] = graphQLMutation(mod, "Save")
+		mutations["delete_"+name] = graphQLDelete(mod)
+	}
+
+	if len(queries) == 0 {
+		return graphql.Schema{}, fmt.Errorf("no models loaded")
+	}
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: queries}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: mutations}),
+	})
+}
+
+// graphQLUsed check if the names of the model are used by the other models or the built-in types
+func graphQLUsed(id string, used []string, names map[string]string, types map[string]string) bool {
+	for i, name := range used {
+		registered := names
+		if i == len(used)-1 {
+			registered = types
+		}
+
+		if other, has := registered[name]; has {
+			if other == "" {
+				other = "the built-in type"
+			}
+			log.Warn("[GraphQL] %s is skipped, the name %s is used by %s", id, name, other)
+			return true
+		}
+	}
+	return false
+}
+
+// graphQLObject the object type of the model, columns are fields and relations are nested fields
+func graphQLObject(mod *model.Model, objects map[string]*graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        graphQLTypeName(mod.ID),
+		Description: mod.MetaData.Name,
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			fields := graphql.Fields{}
+			for _, column := range mod.MetaData.Columns {
+				field := graphQLName(column.Name)
+				if _, has := fields[field]; has {
+					log.Warn("[GraphQL] %s the column %s is skipped, the field %s is used", mod.ID, column.Name, field)
+					continue
+				}
+				fields[field] = &graphql.Field{
+					Type:        graphQLType(column.Type),
+					Description: column.Label,
+					Resolve:     graphQLResolveField(column.Name),
+				}
+			}
+
+			for name, rel := range mod.MetaData.Relations {
+				object, has := objects[rel.Model]
+				if !has {
+					continue
+				}
+
+				field := graphQLName(name)
+				if _, has := fields[field]; has {
+					log.Warn("[GraphQL] %s the relation %s is skipped, the field %s is used", mod.ID, name, field)
+					continue
+				}
+
+				switch rel.Type {
+				case model.RelHasOne, model.RelHasOneThrough:
+					fields[field] = &graphql.Field{Type: object, Resolve: graphQLResolveField(name)}
+				case model.RelHasMany:
+					fields[field] = &graphql.Field{Type: graphql.NewList(object), Resolve: graphQLResolveField(name)}
+				}
+			}
+			return fields
+		}),
+	})
+}
+
+// graphQLFind models.<id>.Find
+func graphQLFind(mod *model.Model, object *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type: object,
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			param := model.QueryParam{Withs: graphQLWiths(mod, p.Info)}
+			return graphQLRun(p.Context, fmt.Sprintf("models.%s.Find", mod.ID), p.Args["id"], param)
+		},
+	}
+}
+
+// graphQLGet models.<id>.Get
+func graphQLGet(mod *model.Model, object *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewList(object),
+		Args: graphql.FieldConfigArgument{
+			"wheres": &graphql.ArgumentConfig{Type: GraphQLJSON},
+			"orders": &graphql.ArgumentConfig{Type: GraphQLJSON},
+			"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			param, ok := model.AnyToQueryParam(p.Args)
+			if !ok {
+				return nil, fmt.Errorf("the query arguments of %s are invalid", p.Info.FieldName)
+			}
+			param.Withs = graphQLWiths(mod, p.Info)
+			return graphQLRun(p.Context, fmt.Sprintf("models.%s.Get", mod.ID), param)
+		},
+	}
+}
+
+// graphQLMutation models.<id>.Create, models.<id>.Save
+func graphQLMutation(mod *model.Model, method string) *graphql.Field {
+	return &graphql.Field{
+		Type: GraphQLJSON,
+		Args: graphql.FieldConfigArgument{
+			"data": &graphql.ArgumentConfig{Type: graphql.NewNonNull(GraphQLJSON)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return graphQLRun(p.Context, fmt.Sprintf("models.%s.%s", mod.ID, method), p.Args["data"])
+		},
+	}
+}
+
+// graphQLDelete models.<id>.Delete
+func graphQLDelete(mod *model.Model) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.Boolean,
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			_, err := graphQLRun(p.Context, fmt.Sprintf("models.%s.Delete", mod.ID), p.Args["id"])
+			if err != nil {
+				return false, err
+			}
+			return true, nil
+		},
+	}
+}
+
+// graphQLRun run the process with the session id and global vars of the request
+func graphQLRun(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	p, err := process.Of(name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if sid, ok := ctx.Value(graphQLSid).(string); ok {
+		p.WithSID(sid)
+	}
+
+	if global, ok := ctx.Value(graphQLGlobal).(map[string]interface{}); ok {
+		p.WithGlobal(global)
+	}
+
+	if id, ok := ctx.Value(graphQLRequestID).(string); ok {
+		p.WithRequestID(id)
+	}
+
+	return p.Exec()
+}
+
+// graphQLWiths convert the selected relation fields to the query withs
+func graphQLWiths(mod *model.Model, info graphql.ResolveInfo) map[string]model.With {
+	withs := map[string]model.With{}
+	models := model.Loaded()
+	for _, field := range info.FieldASTs {
+		graphQLSelectionWiths(models, mod, field.SelectionSet, info.Fragments, withs)
+	}
+	return withs
+}
+
+func graphQLSelectionWiths(models map[string]*model.Model, mod *model.Model, set *ast.SelectionSet, fragments map[string]ast.Definition, withs map[string]model.With) {
+	if set == nil {
+		return
+	}
+
+	for _, selection := range set.Selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			name := sel.Name.Value
+			rel, has := mod.MetaData.Relations[name]
+			if !has {
+				continue
+			}
+
+			with := model.With{Name: name}
+			if relMod, has := models[rel.Model]; has && rel.Type != model.RelHasOneThrough {
+				nested := map[string]model.With{}
+				graphQLSelectionWiths(models, relMod, sel.SelectionSet, fragments, nested)
+				if len(nested) > 0 {
+					with.Query.Withs = nested
+				}
+			}
+			withs[name] = with
+
+		case *ast.InlineFragment:
+			graphQLSelectionWiths(models, mod, sel.SelectionSet, fragments, withs)
+
+		case *ast.FragmentSpread:
+			if fragment, ok := fragments[sel.Name.Value].(*ast.FragmentDefinition); ok {
+				graphQLSelectionWiths(models, mod, fragment.SelectionSet, fragments, withs)
+			}
+		}
+	}
+}
+
+// graphQLResolveField read the field from the query result (maps.MapStr, map[string]interface{} ...)
+func graphQLResolveField(name string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		source := reflect.ValueOf(p.Source)
+		if source.Kind() != reflect.Map || source.Type().Key().Kind() != reflect.String {
+			return nil, nil
+		}
+
+		value := source.MapIndex(reflect.ValueOf(name).Convert(source.Type().Key()))
+		if !value.IsValid() {
+			return nil, nil
+		}
+		return value.Interface(), nil
+	}
+}
+
+// graphQLType the GraphQL type of the column type
+func graphQLType(typ string) graphql.Output {
+	switch strings.ToLower(typ) {
+	case "id", "tinyinteger", "unsignedtinyinteger", "tinyincrements",
+		"smallinteger", "unsignedsmallinteger", "smallincrements",
+		"integer", "unsignedinteger", "increments",
+		"biginteger", "unsignedbiginteger", "bigincrements", "year":
+		return graphql.Int
+
+	case "decimal", "unsigneddecimal", "float", "unsignedfloat", "double", "unsigneddouble":
+		return graphql.Float
+
+	case "boolean":
+		return graphql.Boolean
+
+	case "json", "jsonb":
+		return GraphQLJSON
+	}
+	return graphql.String
+}
+
+// graphQLName user.pet -> user_pet, the characters out of [_0-9A-Za-z] are replaced with _
+func graphQLName(id string) string {
+	name := []rune{}
+	for _, r := range id {
+		if !graphQLNameRune(r) {
+			r = '_'
+		}
+		name = append(name, r)
+	}
+
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		name = append([]rune{'_'}, name...)
+	}
+	return string(name)
+}
+
+// graphQLTypeName user.pet -> UserPet
+func graphQLTypeName(id string) string {
+	name := ""
+	for _, field := range strings.FieldsFunc(id, func(r rune) bool { return r == '_' || !graphQLNameRune(r) }) {
+		name = name + strings.ToUpper(field[:1]) + field[1:]
+	}
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// graphQLNameRune check if the rune could be used in the GraphQL names
+func graphQLNameRune(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// graphQLLiteral convert the literal value to the go value
+func graphQLLiteral(value ast.Value) interface{} {
+	switch v := value.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		if err != nil {
+			return nil
+		}
+		return n
+	case *ast.FloatValue:
+		n, err := strconv.ParseFloat(v.Value, 64)
+		if err != nil {
+			return nil
+		}
+		return n
+	case *ast.ListValue:
+		values := []interface{}{}
+		for _, item := range v.Values {
+			values = append(values, graphQLLiteral(item))
+		}
+		return values
+	case *ast.ObjectValue:
+		values := map[string]interface{}{}
+		for _, field := range v.Fields {
+			values[field.Name.Value] = graphQLLiteral(field.Value)
+		}
+		return values
+	}
+	return nil
+}
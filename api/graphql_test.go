@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/model"
+)
+
+func TestGraphQLSchema(t *testing.T) {
+	prepare(t)
+	defer clean()
+
+	schema, err := GraphQLSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := schema.QueryType().Fields()
+	assert.Contains(t, fields, "user")
+	assert.Contains(t, fields, "user_list")
+
+	mutations := schema.MutationType().Fields()
+	assert.Contains(t, mutations, "create_user")
+	assert.Contains(t, mutations, "save_user")
+	assert.Contains(t, mutations, "delete_user")
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	prepare(t)
+	defer clean()
+
+	router := gin.New()
+	err := SetGraphQL(router, GraphQL{Path: "/graphql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := httptest.NewRecorder()
+	body := []byte(`{"query":"{ user(id: 1) { id name type } }"}`)
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(response, req)
+
+	res := responseMap(response).Dot()
+	assert.Equal(t, float64(1), res.Get("data.user.id"))
+	assert.Equal(t, "U1", res.Get("data.user.name"))
+	assert.Equal(t, "admin", res.Get("data.user.type"))
+}
+
+func TestGraphQLGuard(t *testing.T) {
+	prepare(t)
+	defer clean()
+
+	AddGuard("graphql-deny", func(c *gin.Context) {
+		c.JSON(403, gin.H{"code": 403, "message": "denied"})
+		c.Abort()
+	})
+
+	router := gin.New()
+	err := SetGraphQL(router, GraphQL{Path: "/graphql", Guard: "graphql-deny"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := httptest.NewRecorder()
+	body := []byte(`{"query":"{ user(id: 1) { id } }"}`)
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(response, req)
+	assert.Equal(t, 403, response.Code)
+}
+
+func TestGraphQLDefaultGuard(t *testing.T) {
+	prepare(t)
+	defer clean()
+
+	// the default guard applies if the guard is not set
+	AddGuard(GraphQLGuard, func(c *gin.Context) {
+		c.JSON(401, gin.H{"code": 401, "message": "unauthorized"})
+		c.Abort()
+	})
+
+	router := gin.New()
+	assert.Nil(t, SetGraphQL(router, GraphQL{Path: "/graphql"}))
+	assert.Nil(t, SetGraphQL(router, GraphQL{Path: "/graphql-public", Guard: "-"}))
+
+	body := []byte(`{"query":"{ user(id: 1) { id } }"}`)
+	for path, code := range map[string]int{"/graphql": 401, "/graphql-public": 200} {
+		response := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(response, req)
+		assert.Equal(t, code, response.Code, path)
+	}
+}
+
+func TestGraphQLLiteral(t *testing.T) {
+	value := graphQLLiteral(&ast.ObjectValue{
+		Fields: []*ast.ObjectField{
+			{Name: &ast.Name{Value: "name"}, Value: &ast.StringValue{Value: "U1"}},
+			{Name: &ast.Name{Value: "ids"}, Value: &ast.ListValue{Values: []ast.Value{&ast.IntValue{Value: "1"}, &ast.IntValue{Value: "2"}}}},
+		},
+	})
+	assert.Equal(t, map[string]interface{}{"name": "U1", "ids": []interface{}{1, 2}}, value)
+	assert.Equal(t, "UserPet", graphQLTypeName("user.pet"))
+	assert.Equal(t, "user_pet", graphQLName("user.pet"))
+	assert.Equal(t, "user_pet_v2", graphQLName("user-pet.v2"))
+	assert.Equal(t, "_2fa", graphQLName("2fa"))
+	assert.Equal(t, "UserPetV2", graphQLTypeName("user-pet.v2"))
+	assert.Equal(t, "_2fa", graphQLTypeName("2fa"))
+}
+
+func TestGraphQLSchemaNames(t *testing.T) {
+	prepare(t)
+	defer clean()
+
+	user := model.Models["user"]
+	model.Models["user-pet"] = user
+	model.Models["user.pet"] = user
+	defer delete(model.Models, "user-pet")
+	defer delete(model.Models, "user.pet")
+
+	schema, err := GraphQLSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// user-pet and user.pet are both user_pet, the first one is used
+	fields := schema.QueryType().Fields()
+	assert.Contains(t, fields, "user_pet")
+	assert.Contains(t, fields, "user_pet_list")
+}
+
+func TestGraphQLSchemaReload(t *testing.T) {
+	prepare(t)
+	defer clean()
+
+	cache := &graphQLSchemaCache{}
+	schema, err := cache.get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, schema.QueryType().Fields(), "member")
+
+	model.Models["member"] = model.Models["user"]
+	defer delete(model.Models, "member")
+
+	schema, err = cache.get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, schema.QueryType().Fields(), "member")
+}
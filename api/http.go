@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/url"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -17,6 +16,7 @@ import (
 	"github.com/yaoapp/gou/types"
 	"github.com/yaoapp/kun/any"
 	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/log"
 	"github.com/yaoapp/kun/maps"
 )
 
@@ -69,6 +69,7 @@ func ProcessGuard(name string) gin.HandlerFunc {
 				process.WithGlobal(global)
 			}
 		}
+		withRequestID(c, process)
 
 		process.Run()
 	}
@@ -104,6 +105,9 @@ func IsAllowed(c *gin.Context, allowsMap map[string]bool) bool {
 // Routes 配置转换为路由
 func (http HTTP) Routes(router *gin.Engine, path string, allows ...string) {
 	var group gin.IRoutes = router
+	if Versioning && http.Version != "" {
+		path = filepath.Join(path, "/", versionPrefix(http.Version))
+	}
 	if http.Group != "" {
 		path = filepath.Join(path, "/", http.Group)
 	}
@@ -116,7 +120,7 @@ func (http HTTP) Routes(router *gin.Engine, path string, allows ...string) {
 
 // Route 路径配置转换为路由
 func (http HTTP) Route(router gin.IRoutes, path Path, allows ...string) {
-	getArgs := http.parseIn(path.In)
+	getArgs := http.parseIn(path.In, path.Upload)
 	handlers := []gin.HandlerFunc{}
 
 	// 跨域访问
@@ -151,6 +155,24 @@ func (http HTTP) Route(router gin.IRoutes, path Path, allows ...string) {
 	// 中间件
 	http.guard(&handlers, path.Guard, http.Guard)
 
+	// Deprecation & Sunset (after the guards, the rejected requests are not logged)
+	deprecation, err := http.deprecation(path)
+	if err != nil {
+		log.Error("[API] %s %s %s", path.Method, path.Path, err.Error())
+	} else if deprecation != nil {
+		handlers = append(handlers, deprecation)
+	}
+
+	// Idempotency-Key
+	if path.Idempotent {
+		handlers = append(handlers, http.idempotent())
+	}
+
+	// Response cache
+	if path.Cache != nil {
+		handlers = append(handlers, http.cache(path))
+	}
+
 	// API响应逻辑
 	handlers = append(handlers, func(c *gin.Context) {
 
@@ -175,6 +197,12 @@ func (http HTTP) Route(router gin.IRoutes, path Path, allows ...string) {
 		// 运行 Process
 		var args []interface{} = getArgs(c)
 
+		// Mock mode
+		if path.Mock != nil && (MockMode || path.Process == "" || c.GetHeader(MockHeader) == "true") {
+			http.mock(c, path, args)
+			return
+		}
+
 		// 如果 path.Guard == "in-process" 在调用中鉴权
 		// if path.Guard == "in-process" || (path.Guard == "" && http.Guard == "in-process") {
 		// 	args = append(args, c)
@@ -191,6 +219,7 @@ func (http HTTP) Route(router gin.IRoutes, path Path, allows ...string) {
 				process.WithGlobal(global)
 			}
 		}
+		withRequestID(c, process)
 
 		var resp interface{} = process.Run()
 		var status int = path.Out.Status
@@ -286,6 +315,8 @@ func (http HTTP) guard(handlers *[]gin.HandlerFunc, guard string, defaults strin
 			name = strings.TrimSpace(name)
 			if handler, has := HTTPGuards[name]; has {
 				*handlers = append(*handlers, handler)
+			} else if handler, has := builtinGuards[name]; has {
+				*handlers = append(*handlers, handler)
 			} else { // run process process
 				*handlers = append(*handlers, ProcessGuard(name))
 			}
@@ -316,7 +347,7 @@ func (http HTTP) crossDomain(path string, allows map[string]bool, router gin.IRo
 }
 
 // parseIn 接口传参解析 (这个函数应该重构)
-func (http HTTP) parseIn(in []interface{}) func(c *gin.Context) []interface{} {
+func (http HTTP) parseIn(in []interface{}, upload *Upload) func(c *gin.Context) []interface{} {
 
 	getValues := []func(c *gin.Context) interface{}{}
 	for _, value := range in {
@@ -422,35 +453,19 @@ func (http HTTP) parseIn(in []interface{}) func(c *gin.Context) []interface{} {
 
 		} else if arg[0] == "$file" && length == 2 {
 			getValues = append(getValues, func(c *gin.Context) interface{} {
-
-				file, err := c.FormFile(arg[1])
-
-				ext := filepath.Ext(file.Filename)
-
-				if err != nil {
-					exception.New("读取上传文件出错 %s", 500, err).Throw()
+				files := http.uploads(c, upload)[arg[1]]
+				if len(files) == 0 {
+					exception.New("读取上传文件出错 %s 不存在", 400, arg[1]).Throw()
 				}
-
-				dir, err := ioutil.TempDir(os.TempDir(), "upload")
-				if err != nil {
-					exception.New("创建临时文件夹 %s", 500, err).Throw()
-				}
-
-				tmpfile, err := ioutil.TempFile(dir, fmt.Sprintf("file-*%s", ext))
-				if err != nil {
-					exception.New("创建临时文件出错 %s", 500, err).Throw()
-				}
-
-				err = c.SaveUploadedFile(file, tmpfile.Name())
-				if err != nil {
-					exception.New("保存文件出错 %s", 500, err).Throw()
-				}
-				return types.UploadFile{
-					Name:     file.Filename,
-					TempFile: tmpfile.Name(),
-					Size:     file.Size,
-					Header:   file.Header,
+				return files[0]
+			})
+		} else if arg[0] == "$files" && length == 2 {
+			getValues = append(getValues, func(c *gin.Context) interface{} {
+				files := http.uploads(c, upload)[arg[1]]
+				if files == nil {
+					files = []types.UploadFile{}
 				}
+				return files
 			})
 		} else { // 原始数值
 			new := v
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/yaoapp/gou/store"
+	"github.com/yaoapp/kun/log"
+)
+
+// IdempotencyStore the name of the store which keeps the idempotent responses
+var IdempotencyStore = "__idempotency"
+
+// IdempotencyTimeout how long the idempotent responses are kept
+var IdempotencyTimeout = 24 * time.Hour
+
+// IdempotencyPendingTimeout how long the idempotency key is claimed by the executing request
+var IdempotencyPendingTimeout = 5 * time.Minute
+
+// idempotencyPending the prefix of the value claiming the key, followed by the expiry unix time
+const idempotencyPending = "__pending:"
+
+// storedResponse the stored response (idempotency, cache)
+type storedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    []byte            `json:"body,omitempty"`
+}
+
+// bodyWriter copy the response body while writing it
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// idempotent replay the stored response of the same Idempotency-Key and route
+func (http HTTP) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		key := c.GetHeader("Idempotency-Key")
+		switch c.Request.Method {
+		case "GET", "HEAD", "OPTIONS":
+			key = ""
+		}
+
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		stor, err := useStore(IdempotencyStore)
+		if err != nil {
+			log.Error("[API] %s %s idempotency: %s", c.Request.Method, c.FullPath(), err.Error())
+			c.JSON(500, gin.H{"code": 500, "message": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// the request path (not the route) is used, the same key of the different resources are not mixed
+		id := fmt.Sprintf("idempotency:%s:%s:%s", c.Request.Method, c.Request.URL.Path, key)
+
+		// Replay the stored response
+		if idempotencyReplay(c, stor, id) {
+			return
+		}
+
+		// Claim the key in the store, the instances sharing the store run the request once
+		claimed, err := idempotencyClaim(stor, id)
+		if err != nil {
+			log.Error("[API] %s %s idempotency: %s", c.Request.Method, c.FullPath(), err.Error())
+			c.JSON(500, gin.H{"code": 500, "message": err.Error()})
+			c.Abort()
+			return
+		}
+
+		if !claimed {
+			// The first request could be finished after the stored response was checked
+			if idempotencyReplay(c, stor, id) {
+				return
+			}
+			c.JSON(409, gin.H{"code": 409, "message": fmt.Sprintf("the request with Idempotency-Key %s is being processed", key)})
+			c.Abort()
+			return
+		}
+
+		// The claim is released if the response is not stored
+		stored := false
+		defer func() {
+			if !stored {
+				stor.Del(id)
+			}
+		}()
+
+		writer := &bodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+
+		// Server errors are not stored, the client could retry
+		status := writer.Status()
+		if status >= 500 {
+			return
+		}
+
+		res := storedResponse{Status: status, Headers: map[string]string{}, Body: writer.body.Bytes()}
+		for name, values := range writer.Header() {
+			if len(values) > 0 && !strings.HasPrefix(name, "Access-Control-") {
+				res.Headers[name] = values[0]
+			}
+		}
+
+		text, err := jsoniter.MarshalToString(res)
+		if err != nil {
+			log.Error("[API] %s %s idempotency: %s", c.Request.Method, c.FullPath(), err.Error())
+			return
+		}
+
+		err = stor.Set(id, text, IdempotencyTimeout)
+		if err != nil {
+			log.Error("[API] %s %s idempotency: %s", c.Request.Method, c.FullPath(), err.Error())
+			return
+		}
+		stored = true
+	}
+}
+
+// idempotencyClaim claim the key with a pending marker, return false if the key is claimed by the other request or the response is stored.
+// the expired marker (the stores without ttl, or the instance exited while executing) is removed and the key is claimed again.
+func idempotencyClaim(stor store.Store, id string) (bool, error) {
+	marker := fmt.Sprintf("%s%d", idempotencyPending, time.Now().Add(IdempotencyPendingTimeout).Unix())
+	claimed, err := store.Claim(stor, id, marker, IdempotencyPendingTimeout)
+	if err != nil || claimed {
+		return claimed, err
+	}
+
+	value, ok := stor.Get(id)
+	text, _ := value.(string)
+	if !ok || !strings.HasPrefix(text, idempotencyPending) {
+		return false, nil
+	}
+
+	expires, err := strconv.ParseInt(strings.TrimPrefix(text, idempotencyPending), 10, 64)
+	if err == nil && time.Now().Unix() <= expires {
+		return false, nil
+	}
+
+	stor.Del(id)
+	return store.Claim(stor, id, marker, IdempotencyPendingTimeout)
+}
+
+// idempotencyReplay replay the stored response, return false if the response is not stored
+func idempotencyReplay(c *gin.Context, stor store.Store, id string) bool {
+	value, ok := stor.Get(id)
+	if !ok {
+		return false
+	}
+
+	res := storedResponse{}
+	text, ok := value.(string)
+	if !ok || strings.HasPrefix(text, idempotencyPending) || jsoniter.UnmarshalFromString(text, &res) != nil {
+		return false
+	}
+
+	for name, value := range res.Headers {
+		c.Writer.Header().Set(name, value)
+	}
+	c.Writer.Header().Set("Idempotent-Replayed", "true")
+	c.Data(res.Status, c.Writer.Header().Get("Content-Type"), res.Body)
+	c.Abort()
+	return true
+}
+
+// fallbackStores the in-memory stores used when the named stores are not loaded
+var fallbackStores = map[string]store.Store{}
+var fallbackMutex sync.Mutex
+
+// useStore select the loaded store, use an in-memory store if it does not exist.
+// the in-memory stores are kept by the api package, the store pools are not changed while serving.
+func useStore(name string) (store.Store, error) {
+	if stor, has := store.Pools[name]; has {
+		return stor, nil
+	}
+
+	fallbackMutex.Lock()
+	defer fallbackMutex.Unlock()
+	if stor, has := fallbackStores[name]; has {
+		return stor, nil
+	}
+
+	stor, err := store.New(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Warn("[API] store %s was not loaded, the in-memory store is used", name)
+	fallbackStores[name] = stor
+	return stor, nil
+}
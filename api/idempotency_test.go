@@ -0,0 +1,147 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+)
+
+func TestIdempotentReplay(t *testing.T) {
+	var count int32 = 0
+	process.Register("tests.idempotency.create", func(p *process.Process) interface{} {
+		return map[string]interface{}{"count": atomic.AddInt32(&count, 1)}
+	})
+
+	router := idempotencyRouter()
+	res := idempotencyRequest(router, "key-replay")
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `{"count":1}`, res.Body.String())
+
+	res = idempotencyRequest(router, "key-replay")
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `{"count":1}`, res.Body.String())
+	assert.Equal(t, "true", res.Header().Get("Idempotent-Replayed"))
+
+	res = idempotencyRequest(router, "key-other")
+	assert.Equal(t, `{"count":2}`, res.Body.String())
+
+	res = idempotencyRequest(router, "")
+	assert.Equal(t, `{"count":3}`, res.Body.String())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+}
+
+func TestIdempotentResources(t *testing.T) {
+	var count int32 = 0
+	process.Register("tests.idempotency.create", func(p *process.Process) interface{} {
+		return map[string]interface{}{"count": atomic.AddInt32(&count, 1)}
+	})
+
+	// the same key of the different resources (same route) are not mixed
+	router := idempotencyRouter()
+	res := idempotencyRequest(router, "key-resource", "/idempotency/orders/1")
+	assert.Equal(t, `{"count":1}`, res.Body.String())
+
+	res = idempotencyRequest(router, "key-resource", "/idempotency/orders/2")
+	assert.Equal(t, `{"count":2}`, res.Body.String())
+
+	res = idempotencyRequest(router, "key-resource", "/idempotency/orders/1")
+	assert.Equal(t, `{"count":1}`, res.Body.String())
+}
+
+func TestIdempotentConflict(t *testing.T) {
+	started := make(chan bool)
+	release := make(chan bool)
+	process.Register("tests.idempotency.create", func(p *process.Process) interface{} {
+		started <- true
+		<-release
+		return map[string]interface{}{"done": true}
+	})
+
+	router := idempotencyRouter()
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotencyRequest(router, "key-conflict") }()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first request was not started")
+	}
+
+	res := idempotencyRequest(router, "key-conflict")
+	assert.Equal(t, 409, res.Code)
+
+	release <- true
+	res = <-done
+	assert.Equal(t, 200, res.Code)
+}
+
+func TestIdempotentClaim(t *testing.T) {
+	var count int32 = 0
+	process.Register("tests.idempotency.create", func(p *process.Process) interface{} {
+		return map[string]interface{}{"count": atomic.AddInt32(&count, 1)}
+	})
+
+	stor, err := useStore(IdempotencyStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the key is claimed by the other instance sharing the store
+	id := "idempotency:POST:/idempotency/orders:key-claimed"
+	stor.Set(id, fmt.Sprintf("%s%d", idempotencyPending, time.Now().Add(time.Minute).Unix()), time.Minute)
+	router := idempotencyRouter()
+	res := idempotencyRequest(router, "key-claimed")
+	assert.Equal(t, 409, res.Code)
+
+	// the expired claim is taken over, the response replaces the claim
+	stor.Set(id, fmt.Sprintf("%s%d", idempotencyPending, time.Now().Add(-time.Minute).Unix()), time.Minute)
+	res = idempotencyRequest(router, "key-claimed")
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `{"count":1}`, res.Body.String())
+
+	res = idempotencyRequest(router, "key-claimed")
+	assert.Equal(t, "true", res.Header().Get("Idempotent-Replayed"))
+
+	// the claim is released if the request fails
+	process.Register("tests.idempotency.create", func(p *process.Process) interface{} {
+		panic("failed")
+	})
+	assert.Panics(t, func() { idempotencyRequest(router, "key-failed") })
+	assert.False(t, stor.Has("idempotency:POST:/idempotency/orders:key-failed"))
+}
+
+func idempotencyRouter() *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	http := HTTP{
+		Group: "idempotency",
+		Paths: []Path{
+			{Path: "/orders", Method: "POST", Process: "tests.idempotency.create", Guard: "-", Idempotent: true, Out: Out{Status: 200}},
+			{Path: "/orders/:id", Method: "POST", Process: "tests.idempotency.create", Guard: "-", Idempotent: true, Out: Out{Status: 200}},
+		},
+	}
+	http.Routes(router, "/")
+	return router
+}
+
+func idempotencyRequest(router *gin.Engine, key string, path ...string) *httptest.ResponseRecorder {
+	url := "/idempotency/orders"
+	if len(path) > 0 {
+		url = path[0]
+	}
+
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", url, nil)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	router.ServeHTTP(response, req)
+	return response
+}
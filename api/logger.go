@@ -0,0 +1,97 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	mrand "math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gouhttp "github.com/yaoapp/gou/http"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/log"
+)
+
+// AccessLogging the access log setting, the access logs are disabled when it is nil
+var AccessLogging *AccessLog = nil
+
+var reRequestID = regexp.MustCompile(`^[A-Za-z0-9_\-\.:]{1,128}$`)
+
+// SetAccessLog set the access log setting
+func SetAccessLog(option *AccessLog) {
+	AccessLogging = option
+}
+
+// RequestLogger assign or accept the request id and write the access logs
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(gouhttp.RequestIDHeader)
+		if !reRequestID.MatchString(id) {
+			id = requestID()
+		}
+
+		c.Set("__request_id", id)
+		c.Writer.Header().Set(gouhttp.RequestIDHeader, id)
+
+		start := time.Now()
+		defer accessLog(c, id, start)
+		c.Next()
+	}
+}
+
+// accessLog write the access log, it is deferred, the requests unwound by the panics are logged too
+func accessLog(c *gin.Context, id string, start time.Time) {
+	option := AccessLogging
+	if option == nil {
+		return
+	}
+
+	if option.Sample > 0 && option.Sample < 1 && mrand.Float64() >= option.Sample {
+		return
+	}
+
+	log.With(log.F{
+		"request_id": id,
+		"method":     c.Request.Method,
+		"route":      c.FullPath(),
+		"path":       c.Request.URL.Path,
+		"query":      option.redact(c.Request.URL.Query()),
+		"status":     c.Writer.Status(),
+		"latency":    time.Since(start).Milliseconds(),
+		"bytes":      c.Writer.Size(),
+		"sid":        c.GetString("__sid"),
+		"ip":         c.ClientIP(),
+	}).Info("[API] %s %s %d", c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+}
+
+// redact mask the values of the sensitive fields
+func (option *AccessLog) redact(values map[string][]string) map[string]interface{} {
+	res := map[string]interface{}{}
+	for name, value := range values {
+		res[name] = strings.Join(value, ",")
+	}
+
+	for _, name := range option.Redact {
+		for key := range res {
+			if strings.EqualFold(key, name) {
+				res[key] = "******"
+			}
+		}
+	}
+	return res
+}
+
+// withRequestID pass the request id to the process
+func withRequestID(c *gin.Context, p *process.Process) {
+	if id := c.GetString("__request_id"); id != "" {
+		p.WithRequestID(id)
+	}
+}
+
+func requestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
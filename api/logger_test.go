@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	gouhttp "github.com/yaoapp/gou/http"
+	"github.com/yaoapp/gou/process"
+)
+
+func TestRequestLoggerRequestID(t *testing.T) {
+	process.Register("tests.logger.id", func(p *process.Process) interface{} {
+		return p.RequestID()
+	})
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(RequestLogger())
+	api := HTTP{
+		Group: "logger",
+		Paths: []Path{{Path: "/id", Method: "GET", Guard: "-", Process: "tests.logger.id", Out: Out{Status: 200, Type: "text/plain"}}},
+	}
+	api.Routes(router, "/")
+
+	SetAccessLog(&AccessLog{Redact: []string{"token"}})
+	defer SetAccessLog(nil)
+
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/logger/id?token=secret", nil)
+	req.Header.Set(gouhttp.RequestIDHeader, "req-0001")
+	router.ServeHTTP(response, req)
+	assert.Equal(t, "req-0001", response.Header().Get(gouhttp.RequestIDHeader))
+	assert.Equal(t, "req-0001", response.Body.String())
+
+	response = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/logger/id", nil)
+	router.ServeHTTP(response, req)
+	id := response.Header().Get(gouhttp.RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, response.Body.String())
+}
+
+func TestAccessLogRedact(t *testing.T) {
+	option := &AccessLog{Redact: []string{"Token"}}
+	res := option.redact(map[string][]string{"token": {"secret"}, "page": {"1", "2"}})
+	assert.Equal(t, "******", res["token"])
+	assert.Equal(t, "1,2", res["page"])
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yaoapp/gou/helper"
+	"github.com/yaoapp/kun/maps"
+)
+
+// MockMode return the mock responses instead of running the processes
+var MockMode = false
+
+// MockHeader the request header to return the mock response, eg: X-Api-Mock: true
+var MockHeader = "X-Api-Mock"
+
+// mock response the mock data of the path
+func (http HTTP) mock(c *gin.Context, path Path, args []interface{}) {
+
+	params := map[string]interface{}{}
+	for _, param := range c.Params {
+		params[param.Key] = param.Value
+	}
+
+	query := map[string]interface{}{}
+	for name := range c.Request.URL.Query() {
+		query[name] = c.Query(name)
+	}
+
+	headers := map[string]interface{}{}
+	for name := range c.Request.Header {
+		headers[name] = c.GetHeader(name)
+	}
+
+	payload, has := c.Get("__payloads")
+	if !has {
+		payload = map[string]interface{}{}
+	}
+
+	data := maps.Map{
+		"$in":      args,
+		"$param":   params,
+		"$query":   query,
+		"$payload": payload,
+		"$header":  headers,
+	}.Dot()
+
+	mock := path.Mock
+	status := mock.Status
+	if status == 0 {
+		status = path.Out.Status
+	}
+	if status == 0 {
+		status = 200
+	}
+
+	for name, value := range mock.Headers {
+		if v := helper.Bind(value, data); v != nil {
+			c.Writer.Header().Set(name, fmt.Sprintf("%v", v))
+		}
+	}
+
+	contentType := mock.Type
+	if contentType == "" {
+		contentType = path.Out.Type
+	}
+
+	body := helper.Bind(mock.Body, data)
+	switch value := body.(type) {
+	case nil:
+		c.Status(status)
+	case string:
+		if contentType == "" {
+			contentType = "text/plain; charset=utf-8"
+		}
+		c.Data(status, contentType, []byte(value))
+	default:
+		c.JSON(status, value)
+	}
+	c.Done()
+}
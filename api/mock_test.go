@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+)
+
+func TestMockWithoutProcess(t *testing.T) {
+	router := mockRouter()
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mock/pets/1?name=cat", nil)
+	router.ServeHTTP(response, req)
+
+	res := responseMap(response)
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, "1", res.Get("id"))
+	assert.Equal(t, "cat", res.Get("name"))
+	assert.Equal(t, "mock", response.Header().Get("X-Source"))
+}
+
+func TestMockHeader(t *testing.T) {
+	process.Register("tests.mock.pet", func(p *process.Process) interface{} {
+		return map[string]interface{}{"id": p.Args[0], "name": "real"}
+	})
+
+	router := mockRouter()
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mock/real/2?name=cat", nil)
+	router.ServeHTTP(response, req)
+	assert.Equal(t, "real", responseMap(response).Get("name"))
+
+	response = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/mock/real/2?name=cat", nil)
+	req.Header.Set(MockHeader, "true")
+	router.ServeHTTP(response, req)
+	assert.Equal(t, "cat", responseMap(response).Get("name"))
+
+	MockMode = true
+	defer func() { MockMode = false }()
+	response = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/mock/real/2?name=cat", nil)
+	router.ServeHTTP(response, req)
+	assert.Equal(t, "cat", responseMap(response).Get("name"))
+}
+
+func mockRouter() *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	mock := &Mock{
+		Headers: map[string]string{"X-Source": "mock"},
+		Body:    map[string]interface{}{"id": "{{$param.id}}", "name": "{{$query.name}}"},
+	}
+	http := HTTP{
+		Group: "mock",
+		Paths: []Path{
+			{Path: "/pets/:id", Method: "GET", Guard: "-", Mock: mock},
+			{Path: "/real/:id", Method: "GET", Guard: "-", Process: "tests.mock.pet", In: []interface{}{"$param.id"}, Mock: mock, Out: Out{Status: 200}},
+		},
+	}
+	http.Routes(router, "/")
+	return router
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/exception"
+)
+
+// APIHandlers the api processes
+var APIHandlers = map[string]process.Handler{
+	"cache.clear": processCacheClear,
+	"csrf.token":  processCSRFToken,
+}
+
+func init() {
+	process.RegisterGroup("api", APIHandlers)
+}
+
+// processCacheClear api.cache.Clear(route?) remove the cached responses, return the number of the removed responses
+func processCacheClear(process *process.Process) interface{} {
+	route := ""
+	if process.NumOfArgs() > 0 {
+		route = process.ArgsString(0)
+	}
+	return ClearCache(route)
+}
+
+// processCSRFToken api.csrf.Token(sid?) get the csrf token of the session
+func processCSRFToken(process *process.Process) interface{} {
+	sid := process.Sid
+	if process.NumOfArgs() > 0 {
+		sid = process.ArgsString(0)
+	}
+
+	token, err := CSRFToken(sid)
+	if err != nil {
+		exception.New("csrf token: %s", 400, err.Error()).Throw()
+	}
+	return token
+}
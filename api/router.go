@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yaoapp/kun/log"
+)
+
+// Router the dynamic router, the route table is rebuilt and swapped atomically when the apis are changed.
+// The in-flight requests are completed on the old route table.
+type Router struct {
+	path   string
+	allows []string
+	engine atomic.Value // *gin.Engine
+	mutex  sync.Mutex
+}
+
+// dynamicRouter the dynamic router mounted by SetDynamicRoutes
+var dynamicRouter *Router = nil
+
+// SetDynamicRoutes set the api routes with the dynamic router, the apis could be reloaded without restarting the server.
+// The api requests are dispatched by the NoRoute handler of the router.
+func SetDynamicRoutes(router *gin.Engine, path string, allows ...string) (*Router, error) {
+	r := &Router{path: path, allows: allows}
+	err := r.Refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicRouter = r
+	router.NoRoute(r.Handle)
+	return r, nil
+}
+
+// RefreshRoutes rebuild the route table of the dynamic router, do nothing if the dynamic router is not used
+func RefreshRoutes() error {
+	if dynamicRouter == nil {
+		return nil
+	}
+	return dynamicRouter.Refresh()
+}
+
+// Refresh rebuild the route table with the loaded apis and swap it
+func (r *Router) Refresh() (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// gin panics when the routes are conflicting, keep the old route table
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("refresh routes error: %v", recovered)
+		}
+	}()
+
+	apisMutex.RLock()
+	defer apisMutex.RUnlock()
+
+	engine := gin.New()
+	engine.Use(RequestLogger())
+	engine.Use(recovery())
+	if Versioning {
+		engine.Use(versionSelect(versionRoutes(r.path, r.allows...), r.path))
+	}
+	for _, api := range APIs {
+		api.HTTP.Routes(engine, r.path, r.allows...)
+	}
+
+	r.engine.Store(engine)
+	log.Trace("[API] the route table was refreshed (%d apis)", len(APIs))
+	return nil
+}
+
+// Handle dispatch the request with the current route table
+func (r *Router) Handle(c *gin.Context) {
+	engine, ok := r.engine.Load().(*gin.Engine)
+	if !ok {
+		c.AbortWithStatus(404)
+		return
+	}
+	engine.ServeHTTP(c.Writer, c.Request)
+	c.Abort()
+}
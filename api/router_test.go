@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+)
+
+func TestDynamicRoutes(t *testing.T) {
+	process.Register("tests.router.hello", func(p *process.Process) interface{} {
+		return map[string]interface{}{"hello": p.Args[0]}
+	})
+
+	APIs["tests.router"] = &API{
+		ID:   "tests.router",
+		Type: "http",
+		HTTP: HTTP{Group: "router", Paths: []Path{
+			{Path: "/hello", Method: "GET", Guard: "-", Process: "tests.router.hello", In: []interface{}{"v1"}, Out: Out{Status: 200, Type: "application/json"}},
+		}},
+	}
+	defer func() {
+		delete(APIs, "tests.router")
+		dynamicRouter = nil
+	}()
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	_, err := SetDynamicRoutes(router, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := dynamicRequest(router, "/router/hello")
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, "v1", responseMap(response).Get("hello"))
+
+	// Change the api
+	APIs["tests.router"].HTTP.Paths[0].In = []interface{}{"v2"}
+	APIs["tests.router"].HTTP.Paths = append(APIs["tests.router"].HTTP.Paths,
+		Path{Path: "/world", Method: "GET", Guard: "-", Process: "tests.router.hello", In: []interface{}{"world"}, Out: Out{Status: 200, Type: "application/json"}},
+	)
+	err = RefreshRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response = dynamicRequest(router, "/router/hello")
+	assert.Equal(t, "v2", responseMap(response).Get("hello"))
+	response = dynamicRequest(router, "/router/world")
+	assert.Equal(t, "world", responseMap(response).Get("hello"))
+
+	// Conflicting routes, keep the old route table
+	APIs["tests.router"].HTTP.Paths = append(APIs["tests.router"].HTTP.Paths, APIs["tests.router"].HTTP.Paths[0])
+	assert.NotNil(t, RefreshRoutes())
+	response = dynamicRequest(router, "/router/hello")
+	assert.Equal(t, "v2", responseMap(response).Get("hello"))
+
+	// Remove the api
+	err = Unload("tests.router")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response = dynamicRequest(router, "/router/hello")
+	assert.Equal(t, 404, response.Code)
+}
+
+func TestLoadFiles(t *testing.T) {
+	prepare(t)
+	defer clean()
+
+	err := LoadFiles(map[string]string{"user": "/apis/user.http.yao", "user.copy": "/apis/user.http.yao"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/apis/user.http.yao", Select("user.copy").File)
+	assert.Nil(t, Unload("user.copy"))
+
+	err = LoadFiles(map[string]string{"tests.missing": "/apis/missing.http.yao"})
+	assert.NotNil(t, err)
+}
+
+func dynamicRequest(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	router.ServeHTTP(response, req)
+	return response
+}
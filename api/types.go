@@ -16,6 +16,8 @@ type HTTP struct {
 	Description string `json:"description,omitempty"`
 	Group       string `json:"group,omitempty"`
 	Guard       string `json:"guard,omitempty"`
+	Deprecated  string `json:"deprecated,omitempty"` // the deprecation date of all the paths, eg: 2023-01-01, true
+	Sunset      string `json:"sunset,omitempty"`     // the date when all the paths will be removed, eg: 2023-06-01
 	Paths       []Path `json:"paths,omitempty"`
 }
 
@@ -29,6 +31,12 @@ type Path struct {
 	Guard       string        `json:"guard,omitempty"`
 	In          []interface{} `json:"in,omitempty"`
 	Out         Out           `json:"out,omitempty"`
+	Idempotent  bool          `json:"idempotent,omitempty"` // replay the response of the same Idempotency-Key
+	Mock        *Mock         `json:"mock,omitempty"`       // the mock response, returned instead of running the process in mock mode
+	Upload      *Upload       `json:"upload,omitempty"`     // the limits and the storage of $file, $files
+	Deprecated  string        `json:"deprecated,omitempty"` // the deprecation date, eg: 2023-01-01, true
+	Sunset      string        `json:"sunset,omitempty"`     // the date when the path will be removed, eg: 2023-06-01
+	Cache       *Cache        `json:"cache,omitempty"`      // cache the response of the GET path
 }
 
 // Out http 输出
@@ -40,8 +48,51 @@ type Out struct {
 	Redirect *Redirect         `json:"redirect,omitempty"`
 }
 
+// Mock the mock response of the path, the body could use the request inputs {{$in.0}}, {{$param.id}}, {{$query.name}} ...
+type Mock struct {
+	Status  int               `json:"status,omitempty"`
+	Type    string            `json:"type,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// Cache the response cache setting of the path
+type Cache struct {
+	TTL    int      `json:"ttl,omitempty"`    // seconds, default is 60
+	Vary   []string `json:"vary,omitempty"`   // query, param, session, query.<name>, param.<name>, header.<name>. default is query, param
+	Store  string   `json:"store,omitempty"`  // the name of the store, default is __cache
+	Public bool     `json:"public,omitempty"` // the response is shared by all the users (Cache-Control: public), the session is not varied. default is false
+}
+
+// Upload the upload setting of the path
+type Upload struct {
+	MaxSize    int64    `json:"maxSize,omitempty"`    // the max size of each file (bytes)
+	MimeTypes  []string `json:"mimeTypes,omitempty"`  // the allowed mime types, eg: image/png, image/*
+	FileSystem string   `json:"filesystem,omitempty"` // stream the files into the filesystem instead of the temp dir
+	Dir        string   `json:"dir,omitempty"`        // the dir of the filesystem
+}
+
 // Redirect out redirect
 type Redirect struct {
 	Code     int    `json:"code,omitempty"`
 	Location string `json:"location,omitempty"`
 }
+
+// GraphQL the auto-generated GraphQL endpoint setting
+type GraphQL struct {
+	Path  string `json:"path,omitempty"`
+	Guard string `json:"guard,omitempty"`
+}
+
+// GraphQLRequest the GraphQL request payload
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// AccessLog the access log setting
+type AccessLog struct {
+	Sample float64  `json:"sample,omitempty"` // the sampling rate (0, 1), all of the requests are logged when it is not set
+	Redact []string `json:"redact,omitempty"` // the query names to redact, eg: token, password
+}
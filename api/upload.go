@@ -0,0 +1,255 @@
+package api
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"github.com/yaoapp/gou/fs"
+	"github.com/yaoapp/gou/types"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/log"
+)
+
+// UploadMaxValueSize the max size of each form value in the multipart body (bytes)
+var UploadMaxValueSize int64 = 10 << 20
+
+// uploadMimeSize the size of the header used to detect the mime type
+const uploadMimeSize = 3072
+
+// errUploadTooLarge the file is larger than the max size
+var errUploadTooLarge = errors.New("the file is too large")
+
+// uploadLimitReader count the read bytes, return errUploadTooLarge if the size is over the limit
+type uploadLimitReader struct {
+	reader io.Reader
+	limit  int64
+	size   int64
+}
+
+func (r *uploadLimitReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size = r.size + int64(n)
+	if r.limit > 0 && r.size > r.limit {
+		return n, errUploadTooLarge
+	}
+	return n, err
+}
+
+// uploads stream the multipart files of the request into the filesystem or the temp dir.
+// the body is parsed once, the files are shared by the $file and $files args, the form values are kept in the request PostForm.
+func (http HTTP) uploads(c *gin.Context, option *Upload) map[string][]types.UploadFile {
+	if files, has := c.Get("__uploads"); has {
+		return files.(map[string][]types.UploadFile)
+	}
+
+	files := map[string][]types.UploadFile{}
+
+	// remove the saved files if any of the files is failed
+	defer func() {
+		if r := recover(); r != nil {
+			for _, saved := range files {
+				for _, file := range saved {
+					uploadRemove(file)
+				}
+			}
+			panic(r)
+		}
+	}()
+
+	// the form was parsed before (eg: by the $form args or the guards), the files are buffered already
+	if c.Request.MultipartForm != nil {
+		for name, headers := range c.Request.MultipartForm.File {
+			for _, header := range headers {
+				files[name] = append(files[name], http.upload(header, option))
+			}
+		}
+		c.Set("__uploads", files)
+		return files
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		exception.New("读取上传文件出错 %s", 400, err).Throw()
+	}
+
+	if c.Request.PostForm == nil {
+		c.Request.PostForm = url.Values{}
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			exception.New("读取上传文件出错 %s", 400, err).Throw()
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" {
+			value, err := ioutil.ReadAll(&uploadLimitReader{reader: part, limit: UploadMaxValueSize})
+			if err != nil {
+				exception.New("读取表单 %s 出错 %s", 400, name, err).Throw()
+			}
+			c.Request.PostForm.Add(name, string(value))
+			continue
+		}
+
+		files[name] = append(files[name], uploadSave(part.FileName(), part.Header, part, option))
+		part.Close()
+	}
+
+	c.Set("__uploads", files)
+	return files
+}
+
+// upload save the buffered file (the multipart form was parsed) to the temp dir or the filesystem
+func (http HTTP) upload(file *multipart.FileHeader, option *Upload) types.UploadFile {
+
+	if option != nil && option.MaxSize > 0 && file.Size > option.MaxSize {
+		exception.New("%s is too large (%d > %d)", 413, file.Filename, file.Size, option.MaxSize).Throw()
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		exception.New("read the uploaded file %s error: %s", 500, file.Filename, err).Throw()
+	}
+	defer src.Close()
+	return uploadSave(file.Filename, file.Header, src, option)
+}
+
+// uploadSave check the mime type and the size while streaming the file into the filesystem or the temp dir
+func uploadSave(filename string, header textproto.MIMEHeader, src io.Reader, option *Upload) types.UploadFile {
+
+	if option == nil {
+		option = &Upload{}
+	}
+
+	// Check the size and the mime type with the content header
+	buf := bufio.NewReaderSize(src, uploadMimeSize)
+	head, err := buf.Peek(uploadMimeSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		exception.New("read the uploaded file %s error: %s", 500, filename, err).Throw()
+	}
+
+	if option.MaxSize > 0 && int64(len(head)) > option.MaxSize {
+		uploadThrow(filename, errUploadTooLarge, option)
+	}
+
+	if len(option.MimeTypes) > 0 {
+		mime := mimetype.Detect(head)
+		if !uploadMimeAllowed(mime, option.MimeTypes) {
+			exception.New("the mime type %s of %s is not allowed", 415, mime.String(), filename).Throw()
+		}
+	}
+
+	ext := filepath.Ext(filename)
+	hash := sha256.New()
+	limited := &uploadLimitReader{reader: buf, limit: option.MaxSize}
+	reader := io.TeeReader(limited, hash)
+	res := types.UploadFile{Name: filename, Header: header}
+
+	// Stream into the filesystem
+	if option.FileSystem != "" {
+		xfs := fs.MustGet(option.FileSystem)
+		res.FS = option.FileSystem
+		res.Path = filepath.Join(option.Dir, fmt.Sprintf("%s-%s%s", time.Now().Format("20060102150405"), uploadRandom(), ext))
+		_, err = fs.Write(xfs, res.Path, reader, 0644)
+		if err != nil {
+			uploadRemove(res)
+			uploadThrow(filename, err, option)
+		}
+
+		res.Size = limited.size
+		res.Hash = hex.EncodeToString(hash.Sum(nil))
+		return res
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "upload")
+	if err != nil {
+		exception.New("create the temp dir error: %s", 500, err).Throw()
+	}
+
+	tmpfile, err := ioutil.TempFile(dir, fmt.Sprintf("file-*%s", ext))
+	if err != nil {
+		os.RemoveAll(dir)
+		exception.New("create the temp file error: %s", 500, err).Throw()
+	}
+	defer tmpfile.Close()
+
+	res.TempFile = tmpfile.Name()
+	_, err = io.Copy(tmpfile, reader)
+	if err != nil {
+		uploadRemove(res)
+		uploadThrow(filename, err, option)
+	}
+
+	res.Size = limited.size
+	res.Hash = hex.EncodeToString(hash.Sum(nil))
+	return res
+}
+
+// uploadThrow throw the exception of the failed upload
+func uploadThrow(filename string, err error, option *Upload) {
+	if errors.Is(err, errUploadTooLarge) {
+		exception.New("%s is too large (> %d)", 413, filename, option.MaxSize).Throw()
+	}
+	exception.New("save the uploaded file %s error: %s", 500, filename, err).Throw()
+}
+
+// uploadRemove remove the saved file (the temp dir or the file in the filesystem)
+func uploadRemove(file types.UploadFile) {
+	var err error
+	if file.FS != "" && file.Path != "" {
+		err = fs.Remove(fs.MustGet(file.FS), file.Path)
+	} else if file.TempFile != "" {
+		err = os.RemoveAll(filepath.Dir(file.TempFile))
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn("[API] remove the uploaded file %s error: %s", file.Name, err.Error())
+	}
+}
+
+// uploadMimeAllowed check the mime type, eg: image/png, image/*
+func uploadMimeAllowed(mime *mimetype.MIME, allows []string) bool {
+	for _, allow := range allows {
+		allow = strings.ToLower(strings.TrimSpace(allow))
+		if strings.HasSuffix(allow, "/*") {
+			prefix := strings.TrimSuffix(allow, "*")
+			for m := mime; m != nil; m = m.Parent() {
+				if strings.HasPrefix(m.String(), prefix) {
+					return true
+				}
+			}
+			continue
+		}
+
+		if mime.Is(allow) {
+			return true
+		}
+	}
+	return false
+}
+
+func uploadRandom() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/fs"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/gou/types"
+)
+
+func TestUploadFilesToFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	router := uploadRouter(t, &Upload{FileSystem: "system", Dir: dir, MimeTypes: []string{"text/*"}})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, uploadRequest(t, map[string]string{"a.txt": "hello", "b.txt": "world"}))
+	assert.Equal(t, 200, response.Code)
+
+	res := []types.UploadFile{}
+	err := jsoniter.Unmarshal(response.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, res, 2)
+	for _, file := range res {
+		assert.Equal(t, "system", file.FS)
+		assert.Equal(t, dir, filepath.Dir(file.Path))
+		data, err := fs.ReadFile(fs.MustGet("system"), file.Path)
+		assert.Nil(t, err)
+		sum := sha256.Sum256(data)
+		assert.Equal(t, hex.EncodeToString(sum[:]), file.Hash)
+		assert.Equal(t, int64(len(data)), file.Size)
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	router := uploadRouter(t, &Upload{MaxSize: 8, MimeTypes: []string{"image/png"}})
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, uploadRequest(t, map[string]string{"a.txt": "the content is too large"}))
+	assert.Equal(t, 413, response.Code)
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, uploadRequest(t, map[string]string{"a.txt": "text"}))
+	assert.Equal(t, 415, response.Code)
+}
+
+func TestUploadCleanup(t *testing.T) {
+	dir := t.TempDir()
+	router := uploadRouter(t, &Upload{FileSystem: "system", Dir: dir, MaxSize: 8})
+
+	// the saved files are removed if any of the files is failed
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, uploadRequest(t, map[string]string{"a.txt": "small", "b.txt": strings.Repeat("large", 2048)}))
+	assert.Equal(t, 413, response.Code)
+
+	files, err := fs.ReadDir(fs.MustGet("system"), dir, true)
+	assert.Nil(t, err)
+	assert.Len(t, files, 0)
+}
+
+func uploadRouter(t *testing.T, upload *Upload) *gin.Engine {
+	process.Register("tests.upload.files", func(p *process.Process) interface{} {
+		return p.Args[0]
+	})
+
+	APIs["tests.upload"] = &API{
+		ID:   "tests.upload",
+		Type: "http",
+		HTTP: HTTP{
+			Group: "upload",
+			Paths: []Path{{Path: "/files", Method: "POST", Guard: "-", Process: "tests.upload.files", In: []interface{}{"$files.files"}, Upload: upload, Out: Out{Status: 200, Type: "application/json"}}},
+		},
+	}
+	t.Cleanup(func() { delete(APIs, "tests.upload") })
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	SetRoutes(router, "/")
+	return router
+}
+
+func uploadRequest(t *testing.T, files map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, content := range files {
+		part, err := writer.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/upload/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yaoapp/kun/log"
+)
+
+// Versioning mount the apis with version at /v1/..., /v2/... (the major version of HTTP.Version)
+var Versioning = false
+
+// VersionHeader select the version with the request header when the path has no version prefix
+var VersionHeader = "Accept-Version"
+
+// DefaultVersion the version used when the path has no version prefix and the version header is not set
+var DefaultVersion = ""
+
+// versionPrefix get the path prefix of the version. eg: 1.0.0 => v1, v2 => v2
+func versionPrefix(version string) string {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+	return "v" + strings.Split(version, ".")[0]
+}
+
+// versionKeysKey the request context key of the keys of the dispatching context
+type versionKeysKey struct{}
+
+// versionRoutes the route table of the versioned apis, the header versioned requests are dispatched to it directly.
+// the global middlewares (request id, access logs) of the router are not run again.
+func versionRoutes(root string, allows ...string) *gin.Engine {
+	engine := gin.New()
+	engine.Use(versionKeys(), recovery())
+	for _, api := range APIs {
+		if api.HTTP.Version != "" {
+			api.HTTP.Routes(engine, root, allows...)
+		}
+	}
+	return engine
+}
+
+// versionKeys copy the keys (eg: the request id) of the dispatching context
+func versionKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if keys, ok := c.Request.Context().Value(versionKeysKey{}).(map[string]interface{}); ok {
+			for key, value := range keys {
+				c.Set(key, value)
+			}
+		}
+		c.Next()
+	}
+}
+
+// versionSelect dispatch the unmatched path to the version of the request header, eg: /api/user => /api/v2/user
+func versionSelect(versions *gin.Engine, root string) gin.HandlerFunc {
+	root = filepath.Join("/", root)
+	return func(c *gin.Context) {
+
+		// The route was matched
+		if c.FullPath() != "" {
+			c.Next()
+			return
+		}
+
+		version := c.GetHeader(VersionHeader)
+		if version == "" {
+			version = DefaultVersion
+		}
+
+		path := c.Request.URL.Path
+		if version == "" || !strings.HasPrefix(path, root) {
+			c.Next()
+			return
+		}
+
+		prefix := filepath.Join(root, versionPrefix(version))
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			c.Next()
+			return
+		}
+
+		req := c.Request.Clone(context.WithValue(c.Request.Context(), versionKeysKey{}, c.Keys))
+		req.URL.Path = filepath.Join(prefix, strings.TrimPrefix(path, root))
+		versions.ServeHTTP(c.Writer, req)
+		c.Abort()
+	}
+}
+
+// deprecation emit the Deprecation and Sunset headers and log the calls of the deprecated path
+func (http HTTP) deprecation(path Path) (gin.HandlerFunc, error) {
+
+	deprecated := path.Deprecated
+	if deprecated == "" {
+		deprecated = http.Deprecated
+	}
+
+	sunset := path.Sunset
+	if sunset == "" {
+		sunset = http.Sunset
+	}
+
+	if deprecated == "" && sunset == "" {
+		return nil, nil
+	}
+
+	if deprecated != "" && deprecated != "true" {
+		date, err := deprecationDate(deprecated)
+		if err != nil {
+			return nil, fmt.Errorf("deprecated %s", err.Error())
+		}
+		deprecated = date
+	}
+
+	if sunset != "" {
+		date, err := deprecationDate(sunset)
+		if err != nil {
+			return nil, fmt.Errorf("sunset %s", err.Error())
+		}
+		sunset = date
+	}
+
+	return func(c *gin.Context) {
+		if deprecated != "" {
+			c.Writer.Header().Set("Deprecation", deprecated)
+		}
+		if sunset != "" {
+			c.Writer.Header().Set("Sunset", sunset)
+		}
+		log.With(log.F{
+			"version":    http.Version,
+			"deprecated": deprecated,
+			"sunset":     sunset,
+			"request_id": c.GetString("__request_id"),
+		}).Warn("[API] %s %s is deprecated", c.Request.Method, c.FullPath())
+	}, nil
+}
+
+// deprecationDate parse the date and format it as the HTTP date
+func deprecationDate(value string) (string, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, http.TimeFormat} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.UTC().Format(http.TimeFormat), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a valid date (eg: 2023-01-01)", value)
+}
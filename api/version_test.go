@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	gouhttp "github.com/yaoapp/gou/http"
+	"github.com/yaoapp/gou/process"
+)
+
+func TestVersioning(t *testing.T) {
+	process.Register("tests.version.hello", func(p *process.Process) interface{} {
+		return map[string]interface{}{"version": p.Args[0]}
+	})
+
+	Versioning = true
+	defer func() {
+		Versioning = false
+		DefaultVersion = ""
+	}()
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(RequestLogger())
+	versions := gin.New()
+	versions.Use(versionKeys())
+	router.Use(versionSelect(versions, "/api"))
+	for _, version := range []string{"1.0.0", "2.1.0"} {
+		api := HTTP{
+			Version: version,
+			Group:   "user",
+			Paths:   []Path{{Path: "/hello", Method: "GET", Guard: "-", Process: "tests.version.hello", In: []interface{}{version}, Out: Out{Status: 200, Type: "application/json"}}},
+		}
+		api.Routes(router, "/api")
+		api.Routes(versions, "/api")
+	}
+
+	assert.Equal(t, "1.0.0", responseMap(versionRequest(router, "/api/v1/user/hello", "")).Get("version"))
+	assert.Equal(t, "2.1.0", responseMap(versionRequest(router, "/api/v2/user/hello", "")).Get("version"))
+	assert.Equal(t, "2.1.0", responseMap(versionRequest(router, "/api/user/hello", "v2")).Get("version"))
+	assert.Equal(t, "1.0.0", responseMap(versionRequest(router, "/api/user/hello", "1")).Get("version"))
+	assert.Equal(t, 404, versionRequest(router, "/api/user/hello", "").Code)
+
+	DefaultVersion = "2"
+	assert.Equal(t, "2.1.0", responseMap(versionRequest(router, "/api/user/hello", "")).Get("version"))
+
+	// the request id is assigned once
+	response := versionRequest(router, "/api/user/hello", "1")
+	assert.Len(t, response.Header().Values(gouhttp.RequestIDHeader), 1)
+}
+
+func TestDeprecation(t *testing.T) {
+	process.Register("tests.version.old", func(p *process.Process) interface{} {
+		return map[string]interface{}{"old": true}
+	})
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	api := HTTP{
+		Group:  "deprecated",
+		Sunset: "2030-01-01",
+		Paths: []Path{
+			{Path: "/old", Method: "GET", Guard: "-", Process: "tests.version.old", Deprecated: "2023-01-01", Out: Out{Status: 200, Type: "application/json"}},
+			{Path: "/flag", Method: "GET", Guard: "-", Process: "tests.version.old", Deprecated: "true", Sunset: "2031-01-01T00:00:00Z", Out: Out{Status: 200, Type: "application/json"}},
+		},
+	}
+	api.Routes(router, "/")
+
+	response := versionRequest(router, "/deprecated/old", "")
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, "Sun, 01 Jan 2023 00:00:00 GMT", response.Header().Get("Deprecation"))
+	assert.Equal(t, "Tue, 01 Jan 2030 00:00:00 GMT", response.Header().Get("Sunset"))
+
+	response = versionRequest(router, "/deprecated/flag", "")
+	assert.Equal(t, "true", response.Header().Get("Deprecation"))
+	assert.Equal(t, "Wed, 01 Jan 2031 00:00:00 GMT", response.Header().Get("Sunset"))
+
+	_, err := api.deprecation(Path{Deprecated: "yesterday"})
+	assert.NotNil(t, err)
+
+	// the rejected requests are not deprecated calls
+	AddGuard("tests-deprecated-deny", func(c *gin.Context) {
+		c.AbortWithStatus(403)
+	})
+	defer delete(HTTPGuards, "tests-deprecated-deny")
+
+	router = gin.New()
+	api = HTTP{
+		Group: "denied",
+		Paths: []Path{{Path: "/old", Method: "GET", Guard: "tests-deprecated-deny", Process: "tests.version.old", Deprecated: "2023-01-01", Out: Out{Status: 200, Type: "application/json"}}},
+	}
+	api.Routes(router, "/")
+	response = versionRequest(router, "/denied/old", "")
+	assert.Equal(t, 403, response.Code)
+	assert.Empty(t, response.Header().Get("Deprecation"))
+}
+
+func versionRequest(router *gin.Engine, path string, version string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	if version != "" {
+		req.Header.Set(VersionHeader, version)
+	}
+	router.ServeHTTP(response, req)
+	return response
+}
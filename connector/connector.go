@@ -42,7 +42,11 @@ func Load(file string, id string) (Connector, error) {
 
 // Select a connector
 func Select(id string) (Connector, error) {
-	return nil, nil
+	c, has := Connectors[id]
+	if !has {
+		return nil, fmt.Errorf("connector %s was not loaded", id)
+	}
+	return c, nil
 }
 
 func make(typ string) (Connector, error) {
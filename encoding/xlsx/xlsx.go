@@ -0,0 +1,322 @@
+// Package xlsx read and write the rows of the first sheet of the xlsx files, the rows are streamed.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Writer write the rows to the first sheet of the xlsx file, the cells are written as inline strings or numbers
+type Writer struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	line  int
+}
+
+// Reader read the rows of the first sheet of the xlsx file
+type Reader struct {
+	decoder *xml.Decoder
+	content io.ReadCloser
+	strings []string
+}
+
+var parts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`,
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+}
+
+// NewWriter create a xlsx writer
+func NewWriter(w io.Writer) (*Writer, error) {
+	writer := &Writer{zip: zip.NewWriter(w)}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		part, err := writer.zip.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(part, parts[name])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := writer.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	writer.sheet = sheet
+	return writer, nil
+}
+
+// Write write a row, the nil values are the empty cells
+func (writer *Writer) Write(values []interface{}) error {
+	writer.line++
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<row r="%d">`, writer.line)
+	for i, value := range values {
+		ref := fmt.Sprintf("%s%d", ColumnName(i), writer.line)
+		switch v := value.(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			fmt.Fprintf(buf, `<c r="%s"><v>%v</v></c>`, ref, v)
+		default:
+			fmt.Fprintf(buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(buf, []byte(text(v)))
+			buf.WriteString(`</t></is></c>`)
+		}
+	}
+	buf.WriteString(`</row>`)
+	_, err := writer.sheet.Write(buf.Bytes())
+	return err
+}
+
+// Close close the writer
+func (writer *Writer) Close() error {
+	_, err := io.WriteString(writer.sheet, `</sheetData></worksheet>`)
+	if err != nil {
+		return err
+	}
+	return writer.zip.Close()
+}
+
+// NewReader create a xlsx reader, the xlsx file is a zip archive, it should be read at random positions
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	reader := &Reader{strings: []string{}}
+	if file, has := files["xl/sharedStrings.xml"]; has {
+		reader.strings, err = sharedStrings(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	name := firstSheet(files)
+	file, has := files[name]
+	if !has {
+		return nil, fmt.Errorf("the sheet %s does not exist", name)
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	reader.content = content
+	reader.decoder = xml.NewDecoder(content)
+	return reader, nil
+}
+
+// Read read the next row, the empty cells are nil, the other cells are strings. return io.EOF at the end of the sheet
+func (reader *Reader) Read() ([]interface{}, error) {
+	for {
+		token, err := reader.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		row := struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					Text string `xml:"t"`
+					Runs []struct {
+						Text string `xml:"t"`
+					} `xml:"r"`
+				} `xml:"is"`
+			} `xml:"c"`
+		}{}
+
+		err = reader.decoder.DecodeElement(&row, &start)
+		if err != nil {
+			return nil, err
+		}
+
+		values := []interface{}{}
+		for i, cell := range row.Cells {
+			index := i
+			if cell.Ref != "" {
+				index = ColumnIndex(cell.Ref)
+			}
+			for len(values) <= index {
+				values = append(values, nil)
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err == nil && idx >= 0 && idx < len(reader.strings) {
+					values[index] = reader.strings[idx]
+				}
+			case "inlineStr":
+				text := cell.Inline.Text
+				for _, run := range cell.Inline.Runs {
+					text = text + run.Text
+				}
+				values[index] = text
+			default:
+				if cell.Value != "" {
+					values[index] = cell.Value
+				}
+			}
+		}
+
+		return values, nil
+	}
+}
+
+// Close close the reader
+func (reader *Reader) Close() error {
+	return reader.content.Close()
+}
+
+// sharedStrings read the shared strings
+func sharedStrings(file *zip.File) ([]string, error) {
+	content, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	sst := struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}{}
+
+	err = xml.NewDecoder(content).Decode(&sst)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	for _, item := range sst.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text = text + run.Text
+		}
+		values = append(values, text)
+	}
+	return values, nil
+}
+
+// firstSheet the path of the first sheet of the workbook
+func firstSheet(files map[string]*zip.File) string {
+	name := "xl/worksheets/sheet1.xml"
+	workbook, has := files["xl/workbook.xml"]
+	if !has {
+		return name
+	}
+
+	rels, has := files["xl/_rels/workbook.xml.rels"]
+	if !has {
+		return name
+	}
+
+	wb := struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}{}
+	if err := decode(workbook, &wb); err != nil || len(wb.Sheets) == 0 {
+		return name
+	}
+
+	rs := struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}{}
+	if err := decode(rels, &rs); err != nil {
+		return name
+	}
+
+	for _, rel := range rs.Items {
+		if rel.ID == wb.Sheets[0].ID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/")
+			}
+			return path.Join("xl", rel.Target)
+		}
+	}
+	return name
+}
+
+func decode(file *zip.File, v interface{}) error {
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return xml.NewDecoder(content).Decode(v)
+}
+
+// ColumnName the column name of the index. 0 => A, 26 => AA
+func ColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// ColumnIndex the column index of the cell reference. A1 => 0, AA3 => 26
+func ColumnIndex(ref string) int {
+	index := 0
+	for _, c := range strings.ToUpper(ref) {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		index = index*26 + int(c-'A'+1)
+	}
+	return index - 1
+}
+
+// text the text of the cell
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprintf("%v", value)
+}
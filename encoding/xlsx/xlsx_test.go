@@ -0,0 +1,92 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRead(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer, err := NewWriter(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, writer.Write([]interface{}{"name", "score", "note"}))
+	assert.Nil(t, writer.Write([]interface{}{"foo", 1, nil}))
+	assert.Nil(t, writer.Write([]interface{}{"<bar> & baz", 2.5, "ok"}))
+	assert.Nil(t, writer.Close())
+
+	reader, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	rows := [][]interface{}{}
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, values)
+	}
+
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []interface{}{"name", "score", "note"}, rows[0])
+	assert.Equal(t, []interface{}{"foo", "1"}, rows[1])
+	assert.Equal(t, []interface{}{"<bar> & baz", "2.5", "ok"}, rows[2])
+}
+
+func TestReadSharedStrings(t *testing.T) {
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+	files := map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>name</t></si><si><r><t>fo</t></r><r><t>o</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c></row>` +
+			`<row r="2"><c r="C2" t="s"><v>1</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, content)
+	}
+	archive.Close()
+
+	reader, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	values, err := reader.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"name"}, values)
+
+	values, err = reader.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{nil, nil, "foo"}, values)
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestColumn(t *testing.T) {
+	assert.Equal(t, "A", ColumnName(0))
+	assert.Equal(t, "Z", ColumnName(25))
+	assert.Equal(t, "AA", ColumnName(26))
+	assert.Equal(t, 0, ColumnIndex("A1"))
+	assert.Equal(t, 26, ColumnIndex("AA3"))
+	assert.Equal(t, 27, ColumnIndex("ab10"))
+}
@@ -117,7 +117,11 @@ func (flow *Flow) RunProcess(node *Node, ctx *Context, data maps.Map) (interface
 	}
 
 	if node.Process != "" {
+		root, _ := flow.Global[process.RootKey].(bool)
 		process := process.New(node.Process, args...).WithGlobal(flow.Global).WithSID(flow.Sid)
+		if root {
+			process.WithRoot(true)
+		}
 		resp = process.Run()
 
 		// 当使用 Session start 设置SID时
@@ -3,6 +3,7 @@ package dsl
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 
 	"github.com/yaoapp/gou/fs/system"
 )
@@ -28,6 +29,16 @@ func (f *File) WriteFile(file string, data []byte, perm uint32) (int, error) {
 	return f.File.WriteFile(file, data, perm)
 }
 
+// Write writes the content of the reader to the named file, creating it if necessary.
+// The DSL should be formatted, so the content is read before writing.
+func (f *File) Write(file string, reader io.Reader, perm uint32) (int, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return 0, err
+	}
+	return f.WriteFile(file, data, perm)
+}
+
 // Allow allow rel path
 func (f *File) Allow(patterns ...string) *File {
 	f.File.Allow(patterns...)
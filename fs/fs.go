@@ -1,7 +1,9 @@
 package fs
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 	"time"
@@ -139,6 +141,20 @@ func ReadFile(xfs FileSystem, file string) ([]byte, error) {
 	return xfs.ReadFile(file)
 }
 
+// Open opens the named file for reading, the content is streamed if the filesystem is a FileOpener.
+// The caller should close the reader.
+func Open(xfs FileSystem, file string) (io.ReadCloser, error) {
+	if opener, ok := xfs.(FileOpener); ok {
+		return opener.Open(file)
+	}
+
+	data, err := xfs.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
 // WriteFile writes data to the named file, creating it if necessary.
 //
 //	If the file does not exist, WriteFile creates it with permissions perm (before umask); otherwise WriteFile truncates it before writing, without changing permissions.
@@ -146,6 +162,22 @@ func WriteFile(xfs FileSystem, file string, data []byte, perm uint32) (int, erro
 	return xfs.WriteFile(file, data, perm)
 }
 
+// Write writes the content of the reader to the named file, creating it if necessary.
+// the content is streamed if the filesystem is a FileWriter.
+//
+//	If the file does not exist, Write creates it with permissions perm (before umask); otherwise Write truncates it before writing, without changing permissions.
+func Write(xfs FileSystem, file string, reader io.Reader, perm uint32) (int, error) {
+	if writer, ok := xfs.(FileWriter); ok {
+		return writer.Write(file, reader, perm)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return 0, err
+	}
+	return xfs.WriteFile(file, data, perm)
+}
+
 // ReadDir reads the named directory, returning all its directory entries sorted by filename.
 // If an error occurs reading the directory, ReadDir returns the entries it was able to read before the error, along with the error.
 func ReadDir(xfs FileSystem, dir string, recursive bool) ([]string, error) {
@@ -1,7 +1,9 @@
 package fs
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -195,6 +197,44 @@ func TestWriteFile(t *testing.T) {
 	}
 }
 
+func TestWrite(t *testing.T) {
+	stores := testStores(t)
+	f := testFiles(t)
+	for name, stor := range stores {
+		clear(stor, t)
+		data := testData(t)
+
+		// Write
+		length, err := Write(stor, f["F1"], bytes.NewReader(data), 0644)
+		assert.Nil(t, err, name)
+		assert.Equal(t, len(data), length, name)
+		checkFileExists(stor, t, f["F1"], name)
+		checkFileSize(stor, t, f["F1"], length, name)
+		checkFileMode(stor, t, f["F1"], 0644, name)
+
+		content, err := ReadFile(stor, f["F1"])
+		assert.Nil(t, err, name)
+		assert.Equal(t, data, content, name)
+
+		// Create the dir
+		length, err = Write(stor, f["D1_D2_F1"], bytes.NewReader(data), 0644)
+		assert.Nil(t, err, name)
+		checkFileExists(stor, t, f["D1_D2_F1"], name)
+		checkFileSize(stor, t, f["D1_D2_F1"], length, name)
+
+		// The filesystem is not a FileWriter
+		length, err = Write(plainFS{stor}, f["F2"], bytes.NewReader(data), 0644)
+		assert.Nil(t, err, name)
+		assert.Equal(t, len(data), length, name)
+		content, err = ReadFile(stor, f["F2"])
+		assert.Nil(t, err, name)
+		assert.Equal(t, data, content, name)
+	}
+}
+
+// plainFS the filesystem without the optional interfaces
+type plainFS struct{ FileSystem }
+
 func TestReadFile(t *testing.T) {
 	stores := testStores(t)
 	f := testFiles(t)
@@ -226,6 +266,29 @@ func TestReadFile(t *testing.T) {
 	}
 }
 
+func TestOpen(t *testing.T) {
+	stores := testStores(t)
+	f := testFiles(t)
+	for name, stor := range stores {
+		clear(stor, t)
+		data := testData(t)
+
+		_, err := WriteFile(stor, f["F1"], data, 0644)
+		assert.Nil(t, err, name)
+
+		reader, err := Open(stor, f["F1"])
+		assert.Nil(t, err, name)
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		assert.Nil(t, err, name)
+		assert.Equal(t, data, content, name)
+
+		// file does not exist
+		_, err = Open(stor, f["F2"])
+		assert.NotNil(t, err, name)
+	}
+}
+
 func TestRemove(t *testing.T) {
 	stores := testStores(t)
 	f := testFiles(t)
@@ -73,6 +73,15 @@ func (f *File) ReadFile(file string) ([]byte, error) {
 	return os.ReadFile(file)
 }
 
+// Open opens the named file for reading, the caller should close it.
+func (f *File) Open(file string) (io.ReadCloser, error) {
+	file, err := f.absPath(file)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(file)
+}
+
 // WriteFile writes data to the named file, creating it if necessary.
 //
 //	If the file does not exist, WriteFile creates it with permissions perm (before umask); otherwise WriteFile truncates it before writing, without changing permissions.
@@ -96,6 +105,35 @@ func (f *File) WriteFile(file string, data []byte, perm uint32) (int, error) {
 	return len(data), err
 }
 
+// Write writes the content of the reader to the named file, creating it if necessary.
+//
+//	If the file does not exist, Write creates it with permissions perm (before umask); otherwise Write truncates it before writing, without changing permissions.
+func (f *File) Write(file string, reader io.Reader, perm uint32) (int, error) {
+	file, err := f.absPath(file)
+	if err != nil {
+		return 0, err
+	}
+
+	dir := filepath.Dir(file)
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil && !os.IsExist(err) {
+		return 0, err
+	}
+
+	fd, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fs.FileMode(perm))
+	if err != nil {
+		return 0, err
+	}
+	defer fd.Close()
+
+	n, err := io.Copy(fd, reader)
+	if err != nil {
+		return int(n), err
+	}
+
+	return int(n), nil
+}
+
 // ReadDir reads the named directory, returning all its directory entries sorted by filename.
 // If an error occurs reading the directory, ReadDir returns the entries it was able to read before the error, along with the error.
 func (f *File) ReadDir(dir string, recursive bool) ([]string, error) {
@@ -1,6 +1,9 @@
 package fs
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 // FileSystem the filesystem io interface
 type FileSystem interface {
@@ -30,3 +33,13 @@ type FileSystem interface {
 
 	MimeType(name string) (string, error)
 }
+
+// FileWriter the filesystem could write the file from a stream, fs.Write reads the whole stream if the filesystem does not support
+type FileWriter interface {
+	Write(file string, reader io.Reader, perm uint32) (int, error)
+}
+
+// FileOpener the filesystem could open the file as a stream, fs.Open reads the whole file if the filesystem does not support
+type FileOpener interface {
+	Open(file string) (io.ReadCloser, error)
+}
@@ -10,6 +10,9 @@ import (
 
 var fileRoot = ""
 
+// RequestIDHeader the header name of the request id
+var RequestIDHeader = "X-Request-ID"
+
 // HTTPHandlers the http handlers
 var HTTPHandlers = map[string]process.Handler{
 	"get":    processHTTPGet,
@@ -279,5 +282,10 @@ func processHTTPNew(process *process.Process, from int) (*Request, *Response) {
 		req.WithHeader(headers)
 	}
 
+	// Forward the request id
+	if id := process.RequestID(); id != "" && !req.HasHeader(RequestIDHeader) {
+		req.SetHeader(RequestIDHeader, id)
+	}
+
 	return req, nil
 }
@@ -363,3 +363,23 @@ func testHanlder(c *gin.Context) {
 		"headers": c.Request.Header,
 	})
 }
+
+func TestHTTPRequestID(t *testing.T) {
+
+	shutdown, ready, host := processSetup()
+	go processStart(t, &host, shutdown, ready)
+	defer processStop(shutdown, ready)
+	<-ready
+
+	p := process.New("http.Get", fmt.Sprintf("%s/get", host))
+	p.WithRequestID("req-0001")
+	v := p.Run()
+
+	resp, ok := v.(*Response)
+	if !ok {
+		t.Fatal(fmt.Errorf("response error %#v", v))
+	}
+	assert.Equal(t, 200, resp.Status)
+	res := any.Of(resp.Data).MapStr().Dot()
+	assert.Equal(t, "req-0001", res.Get("headers.X-Request-Id[0]"))
+}
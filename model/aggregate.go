@@ -0,0 +1,167 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/dbal"
+	"github.com/yaoapp/xun/dbal/query"
+)
+
+// aggregateFuncs the supported aggregate functions
+var aggregateFuncs = map[string]bool{"count": true, "sum": true, "avg": true, "min": true, "max": true}
+
+// aggregateAlias the export name of the aggregate expression
+var aggregateAlias = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Aggregate 分组统计查询
+func (mod *Model) Aggregate(param QueryParam) ([]maps.MapStr, error) {
+	if !param.isAggregate() {
+		return nil, fmt.Errorf("the aggregates or groups are required")
+	}
+
+	param.Model = mod.Name
+	param.bind(mod)
+	param.Withs = nil
+	stack := NewQueryStack(param)
+	res := stack.Run()
+	return res, nil
+}
+
+// MustAggregate 分组统计查询, 失败抛出异常
+func (mod *Model) MustAggregate(param QueryParam) []maps.MapStr {
+	res, err := mod.Aggregate(param)
+	if err != nil {
+		exception.Err(err, 400).Throw()
+	}
+	return res
+}
+
+// isAggregate check if the query has the groups or the aggregates
+func (param QueryParam) isAggregate() bool {
+	return len(param.Groups) > 0 || len(param.Aggregates) > 0
+}
+
+// groupSelect the default select of the aggregate query (the group columns)
+func (param QueryParam) groupSelect() []interface{} {
+	selects := []interface{}{}
+	for _, group := range param.Groups {
+		selects = append(selects, group)
+	}
+	return selects
+}
+
+// aggregate 分组统计 (Groups, Aggregates, Havings)
+func (param QueryParam) aggregate(qb query.Query, mod *Model) {
+
+	// Aggregates
+	exprs := map[string]string{}
+	for _, agg := range param.Aggregates {
+		expr, alias := param.aggregateExpr(agg, mod)
+		if _, has := exprs[alias]; has {
+			exception.New("aggregate %s is duplicated", 400, alias).Throw()
+		}
+		exprs[alias] = expr
+		qb.SelectAppend(dbal.Raw(expr + " as " + alias))
+	}
+
+	// Groups
+	for _, group := range param.Groups {
+		if _, has := mod.Columns[group]; !has {
+			exception.New("group column %s does not exist", 400, group).Throw()
+		}
+		mod.checkReadable(mod.acl(), group)
+		qb.GroupBy(mod.FliterWhere(param.Alias, group))
+	}
+
+	// Havings
+	for _, having := range param.Havings {
+		var column interface{}
+		if expr, has := exprs[having.Column]; has {
+			column = dbal.Raw(expr)
+		} else if param.hasGroup(having.Column) {
+			column = mod.FliterWhere(param.Alias, having.Column)
+		} else {
+			exception.New("having column %s should be an aggregate or a group column", 400, having.Column).Throw()
+		}
+
+		op, has := opmap[having.OP]
+		if !has || op == "like" {
+			op = "="
+		}
+
+		if strings.ToLower(having.Method) == "orhaving" {
+			qb.OrHaving(column, op, having.Value)
+			continue
+		}
+		qb.Having(column, op, having.Value)
+	}
+}
+
+// aggregateExpr the aggregate expression and the export name. eg: SUM(orders.amount), sum_amount
+func (param QueryParam) aggregateExpr(agg QueryAggregate, mod *Model) (string, string) {
+	fn := strings.ToLower(agg.Func)
+	if !aggregateFuncs[fn] {
+		exception.New("aggregate function %s is not supported", 400, agg.Func).Throw()
+	}
+
+	field := "*"
+	alias := fn
+	if agg.Column != "" && agg.Column != "*" {
+		column, has := mod.Columns[agg.Column]
+		if !has {
+			exception.New("aggregate column %s does not exist", 400, agg.Column).Throw()
+		}
+
+		// 加密字段仅支持计数
+		if column.Crypt != "" && fn != "count" {
+			exception.New("aggregate column %s is encrypted", 400, agg.Column).Throw()
+		}
+
+		// 无读权限的字段不可统计
+		if !mod.acl().readable(agg.Column) {
+			exception.New("aggregate column %s is not readable", 403, agg.Column).Throw()
+		}
+
+		field = agg.Column
+		if param.Alias != "" {
+			field = param.Alias + "." + agg.Column
+		}
+
+		// 虚拟字段使用 SQL 表达式
+		if column.Virtual != nil {
+			field = column.virtualExpression(mod, param.Alias)
+		}
+		alias = fn + "_" + agg.Column
+
+	} else if fn != "count" {
+		exception.New("aggregate %s: the column is required", 400, fn).Throw()
+	}
+
+	if agg.Distinct {
+		field = "DISTINCT " + field
+	}
+
+	if agg.Alias != "" {
+		alias = agg.Alias
+	}
+
+	if !aggregateAlias.MatchString(alias) {
+		exception.New("aggregate alias %s is invalid", 400, alias).Throw()
+	}
+
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(fn), field), alias
+}
+
+// hasGroup 检查分组字段是否存在
+func (param QueryParam) hasGroup(column string) bool {
+	for _, group := range param.Groups {
+		if group == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestAggregate(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+
+	mod := prepareTests(t, "tests.aggregate")
+
+	for _, row := range []maps.MapStrAny{
+		{"category": "food", "amount": 10},
+		{"category": "food", "amount": 20},
+		{"category": "book", "amount": 5},
+		{"category": "toy", "amount": 100},
+	} {
+		mod.MustCreate(row)
+	}
+
+	// The deleted rows are ignored
+	toy := mod.MustGet(QueryParam{Wheres: []QueryWhere{{Column: "category", Value: "toy"}}})
+	mod.MustDelete(toy[0].Get("id"))
+
+	rows := mod.MustAggregate(QueryParam{
+		Groups: []string{"category"},
+		Aggregates: []QueryAggregate{
+			{Func: "count", Alias: "total"},
+			{Func: "sum", Column: "amount"},
+		},
+		Orders: []QueryOrder{{Column: "category"}},
+	})
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "book", rows[0].Get("category"))
+	assert.Equal(t, 1, any.Of(rows[0].Get("total")).CInt())
+	assert.Equal(t, "food", rows[1].Get("category"))
+	assert.Equal(t, 2, any.Of(rows[1].Get("total")).CInt())
+	assert.Equal(t, 30, any.Of(rows[1].Get("sum_amount")).CInt())
+
+	// Having
+	res := process.New("models.tests.aggregate.Aggregate", map[string]interface{}{
+		"groups":     []string{"category"},
+		"aggregates": []map[string]interface{}{{"func": "max", "column": "amount", "alias": "top"}},
+		"havings":    []map[string]interface{}{{"column": "top", "op": "gt", "value": 10}},
+	}).Run()
+	rows, ok := res.([]maps.MapStr)
+	if !ok {
+		t.Fatal("the result is not a list of rows")
+	}
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "food", rows[0].Get("category"))
+	assert.Equal(t, 20, any.Of(rows[0].Get("top")).CInt())
+
+	// Invalid
+	assert.Panics(t, func() {
+		mod.MustAggregate(QueryParam{Aggregates: []QueryAggregate{{Func: "sum", Column: "unknown"}}})
+	})
+	assert.Panics(t, func() {
+		mod.MustAggregate(QueryParam{Aggregates: []QueryAggregate{{Func: "median", Column: "amount"}}})
+	})
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -8,13 +9,25 @@ import (
 	"github.com/yaoapp/kun/exception"
 	"github.com/yaoapp/kun/log"
 	"github.com/yaoapp/kun/maps"
-	"github.com/yaoapp/xun/capsule"
 	"github.com/yaoapp/xun/dbal"
 )
 
+// errDeleteNotFound no rows deleted
+var errDeleteNotFound = errors.New("no rows deleted")
+
 // Find 查询单条记录
 func (mod *Model) Find(id interface{}, param QueryParam) (maps.MapStr, error) {
+	row, err := mod.find(id, param)
+	if err != nil {
+		return nil, err
+	}
+	return mod.afterFind(row), nil
+}
+
+// find 查询单条记录 (不执行 afterFind)
+func (mod *Model) find(id interface{}, param QueryParam) (maps.MapStr, error) {
 	param.Model = mod.Name
+	param.bind(mod)
 	param.Wheres = []QueryWhere{
 		{
 			Column: mod.PrimaryKey,
@@ -42,8 +55,12 @@ func (mod *Model) MustFind(id interface{}, param QueryParam) maps.MapStr {
 // Get 按条件查询, 不分页
 func (mod *Model) Get(param QueryParam) ([]maps.MapStr, error) {
 	param.Model = mod.Name
+	param.bind(mod)
 	stack := NewQueryStack(param)
 	res := stack.Run()
+	for i := range res {
+		res[i] = mod.afterFind(res[i])
+	}
 	return res, nil
 }
 
@@ -59,6 +76,7 @@ func (mod *Model) MustGet(param QueryParam) []maps.MapStr {
 // Paginate 按条件查询, 分页
 func (mod *Model) Paginate(param QueryParam, page int, pagesize int) (maps.MapStr, error) {
 	param.Model = mod.Name
+	param.bind(mod)
 	stack := NewQueryStack(param)
 	res := stack.Paginate(page, pagesize)
 	return res, nil
@@ -76,6 +94,7 @@ func (mod *Model) MustPaginate(param QueryParam, page int, pagesize int) maps.Ma
 // Create 创建单条数据, 返回新创建数据ID
 func (mod *Model) Create(row maps.MapStrAny) (int, error) {
 
+	mod.before(mod.MetaData.Hooks.BeforeCreate, row)
 	errs := mod.Validate(row) // 输入数据校验
 	if len(errs) > 0 {
 		msgs := []string{}
@@ -86,13 +105,21 @@ func (mod *Model) Create(row maps.MapStrAny) (int, error) {
 		exception.New("输入参数错误\n %s", 400, strings.Join(msgs, "\n")).Ctx(errs).Throw()
 	}
 
+	snapshot := mod.auditSnapshot(row)
+	input := hookRow(row)
 	mod.FliterIn(row) // 入库前输入数据预处理
+	mod.versionReset(row)
+	mod.setTenant(row)
 
 	if mod.MetaData.Option.Timestamps {
 		row.Set("created_at", dbal.Raw("CURRENT_TIMESTAMP"))
 	}
 
-	id, err := capsule.Query().
+	if mod.MetaData.Option.Trackings {
+		mod.setTracking(row, "created_by")
+	}
+
+	id, err := mod.newQuery().
 		Table(mod.MetaData.Table.Name).
 		InsertGetID(row)
 
@@ -100,6 +127,8 @@ func (mod *Model) Create(row maps.MapStrAny) (int, error) {
 		return 0, err
 	}
 
+	mod.audit(AuditInsert, id, nil, snapshot)
+	mod.after(mod.MetaData.Hooks.AfterCreate, int(id), input)
 	return int(id), err
 }
 
@@ -115,7 +144,8 @@ func (mod *Model) MustCreate(row maps.MapStrAny) int {
 // Update 更新单条数据
 func (mod *Model) Update(id interface{}, row maps.MapStrAny) error {
 
-	errs := mod.Validate(row) // 输入数据校验
+	mod.before(mod.MetaData.Hooks.BeforeSave, row, id)
+	errs := mod.Validate(row, id) // 输入数据校验
 	if len(errs) > 0 {
 		msgs := []string{}
 		for _, err := range errs {
@@ -125,22 +155,41 @@ func (mod *Model) Update(id interface{}, row maps.MapStrAny) error {
 		exception.New("输入参数错误\n %s", 400, strings.Join(msgs, "\n")).Ctx(errs).Throw()
 	}
 
+	snapshot := mod.auditSnapshot(row)
+	olds := mod.auditFind(QueryParam{Wheres: []QueryWhere{{Column: mod.PrimaryKey, Value: id}}, Limit: 1})
+	input := hookRow(row)
 	mod.FliterIn(row) // 入库前输入数据预处理
+	mod.unsetTenant(row)
+	version, row := mod.versionOf(row)
 
 	if mod.MetaData.Option.Timestamps {
 		row.Set("updated_at", dbal.Raw("CURRENT_TIMESTAMP"))
 	}
 
-	effect, err := capsule.Query().
+	if mod.MetaData.Option.Trackings {
+		mod.setTracking(row, "updated_by")
+	}
+
+	qb := mod.newQuery().
 		Table(mod.MetaData.Table.Name).
-		Where(mod.PrimaryKey, id).
-		Limit(1).
-		Update(row)
+		Where(mod.PrimaryKey, id)
 
-	if effect == 0 {
+	mod.tenantWhere(qb)
+	if version != nil {
+		qb.Where(VersionColumn, version)
+	}
+
+	effect, err := qb.Limit(1).Update(row)
+	if effect == 0 && err == nil {
+		mod.versionConflict(id, version)
 		return fmt.Errorf("没有数据被更新")
 	}
 
+	if err == nil {
+		mod.auditRows(AuditUpdate, olds, snapshot)
+		mod.after(mod.MetaData.Hooks.AfterSave, id, input)
+	}
+
 	return err
 }
 
@@ -155,6 +204,11 @@ func (mod *Model) MustUpdate(id interface{}, row maps.MapStrAny) {
 // Save 保存单条数据, 不存在创建记录, 存在更新记录,  返回数据ID
 func (mod *Model) Save(row maps.MapStrAny) (interface{}, error) {
 
+	mod.before(mod.MetaData.Hooks.BeforeSave, row)
+	if !row.Has(mod.PrimaryKey) {
+		mod.before(mod.MetaData.Hooks.BeforeCreate, row)
+	}
+
 	errs := mod.Validate(row) // 输入数据校验
 	if len(errs) > 0 {
 		msgs := []string{}
@@ -165,6 +219,8 @@ func (mod *Model) Save(row maps.MapStrAny) (interface{}, error) {
 		exception.New("输入参数错误\n %s", 400, strings.Join(msgs, "\n")).Ctx(errs).Throw()
 	}
 
+	snapshot := mod.auditSnapshot(row)
+	input := hookRow(row)
 	mod.FliterIn(row) // 入库前输入数据预处理
 
 	// 更新
@@ -176,28 +232,55 @@ func (mod *Model) Save(row maps.MapStrAny) (interface{}, error) {
 			row.Del("created_at") // 忽略创建字段
 		}
 
+		if mod.MetaData.Option.Trackings {
+			row.Del("deleted_by") // 忽略删除人
+			row.Del("created_by") // 忽略创建人
+			mod.setTracking(row, "updated_by")
+		}
+
 		id := row.Get(mod.PrimaryKey)
-		_, err := capsule.Query().
+		mod.unsetTenant(row)
+		version, row := mod.versionOf(row)
+		olds := mod.auditFind(QueryParam{Wheres: []QueryWhere{{Column: mod.PrimaryKey, Value: id}}, Limit: 1})
+		qb := mod.newQuery().
 			Table(mod.MetaData.Table.Name).
-			Where(mod.PrimaryKey, id).
-			Limit(1).
-			Update(row)
+			Where(mod.PrimaryKey, id)
 
+		mod.tenantWhere(qb)
+		if version != nil {
+			qb.Where(VersionColumn, version)
+		}
+
+		effect, err := qb.Limit(1).Update(row)
 		if err != nil {
 			return 0, err
 		}
 
+		if effect == 0 {
+			mod.versionConflict(id, version)
+		}
+
+		mod.auditRows(AuditUpdate, olds, snapshot)
+		mod.after(mod.MetaData.Hooks.AfterSave, id, input)
 		return id, nil
 	}
 
 	// 创建
+	mod.versionReset(row)
+	mod.setTenant(row)
 	if mod.MetaData.Option.Timestamps {
 		row.Set("created_at", dbal.Raw("CURRENT_TIMESTAMP"))
 		row.Del("deleted_at") // 忽略删除字段
 		row.Del("updated_at") // 忽略更新字段
 	}
 
-	id, err := capsule.Query().
+	if mod.MetaData.Option.Trackings {
+		row.Del("deleted_by") // 忽略删除人
+		row.Del("updated_by") // 忽略更新人
+		mod.setTracking(row, "created_by")
+	}
+
+	id, err := mod.newQuery().
 		Table(mod.MetaData.Table.Name).
 		InsertGetID(row)
 
@@ -205,6 +288,9 @@ func (mod *Model) Save(row maps.MapStrAny) (interface{}, error) {
 		return 0, err
 	}
 
+	mod.audit(AuditInsert, id, nil, snapshot)
+	mod.after(mod.MetaData.Hooks.AfterCreate, id, input)
+	mod.after(mod.MetaData.Hooks.AfterSave, id, input)
 	return id, err
 }
 
@@ -219,7 +305,9 @@ func (mod *Model) MustSave(row maps.MapStrAny) interface{} {
 
 // Delete 删除单条记录
 func (mod *Model) Delete(id interface{}) error {
-	_, err := mod.DeleteWhere(QueryParam{
+	mod.beforeDelete(id)
+
+	effect, err := mod.DeleteWhere(QueryParam{
 		Wheres: []QueryWhere{
 			{
 				Column: mod.PrimaryKey,
@@ -228,12 +316,26 @@ func (mod *Model) Delete(id interface{}) error {
 		},
 		Limit: 1,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	// 未删除数据 (不存在, 已删除或其他租户的数据) 不执行 afterDelete
+	if effect == 0 {
+		return fmt.Errorf("%s %v: %w", mod.ID, id, errDeleteNotFound)
+	}
+
+	mod.after(mod.MetaData.Hooks.AfterDelete, id)
+	return nil
 }
 
-// MustDelete 删除单条记录, 失败抛出异常
+// MustDelete 删除单条记录, 失败抛出异常 (数据不存在返回 404)
 func (mod *Model) MustDelete(id interface{}) {
 	err := mod.Delete(id)
+	if errors.Is(err, errDeleteNotFound) {
+		exception.Err(err, 404).Throw()
+	}
+
 	if err != nil {
 		exception.Err(err, 500).Throw()
 	}
@@ -241,7 +343,13 @@ func (mod *Model) MustDelete(id interface{}) {
 
 // Destroy 真删除单条记录
 func (mod *Model) Destroy(id interface{}) error {
-	_, err := capsule.Query().Table(mod.MetaData.Table.Name).Where(mod.PrimaryKey, id).Limit(1).Delete()
+	olds := mod.auditFind(QueryParam{Wheres: []QueryWhere{{Column: mod.PrimaryKey, Value: id}}, Limit: 1})
+	qb := mod.newQuery().Table(mod.MetaData.Table.Name).Where(mod.PrimaryKey, id)
+	mod.tenantWhere(qb)
+	_, err := qb.Limit(1).Delete()
+	if err == nil {
+		mod.auditRows(AuditDelete, olds, nil)
+	}
 	return err
 }
 
@@ -256,8 +364,12 @@ func (mod *Model) MustDestroy(id interface{}) {
 // Insert 插入多条数据
 func (mod *Model) Insert(columns []string, rows [][]interface{}) error {
 
+	// 虚拟字段不可写入
+	columns, rows = mod.virtualDrop(columns, rows)
+
 	// 数据校验
 	errs := []ValidateResponse{}
+	snapshots := []maps.MapStrAny{}
 	columnCnt := len(columns)
 	for rid, values := range rows {
 
@@ -283,6 +395,9 @@ func (mod *Model) Insert(columns []string, rows [][]interface{}) error {
 		}
 
 		// 入库前输入数据预处理
+		if snapshot := mod.auditSnapshot(row); snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
 		mod.FliterIn(row)
 		values := []interface{}{}
 		for _, name := range columns {
@@ -298,6 +413,9 @@ func (mod *Model) Insert(columns []string, rows [][]interface{}) error {
 		exception.New("输入参数错误", 400).Ctx(errs).Throw()
 	}
 
+	// 添加租户
+	columns = mod.tenantValues(columns, rows)
+
 	// 添加创建时间戳
 	if mod.MetaData.Option.Timestamps {
 		columns = append(columns, "created_at")
@@ -306,11 +424,53 @@ func (mod *Model) Insert(columns []string, rows [][]interface{}) error {
 		}
 	}
 
+	// 添加创建人
+	if user := mod.trackingUser(); user != nil && !hasColumn(columns, "created_by") {
+		columns = append(columns, "created_by")
+		for i := range rows {
+			rows[i] = append(rows[i], user)
+		}
+	}
+
+	// 记录变更日志, 需逐条写入以获取自增主键
+	if len(snapshots) > 0 {
+		return mod.insertAudited(columns, rows, snapshots)
+	}
+
 	// 写入到数据库
-	return capsule.Query().
+	return mod.newQuery().
 		Table(mod.MetaData.Table.Name).
 		Insert(rows, columns)
+}
+
+// insertAudited insert the rows one by one in a transaction to resolve the auto-increment keys of the audit logs
+func (mod *Model) insertAudited(columns []string, rows [][]interface{}, snapshots []maps.MapStrAny) error {
+	if mod.tx == nil {
+		return Transaction(mod.MetaData.Connector, func(tx *Tx) error {
+			return mod.withTx(tx).insertAudited(columns, rows, snapshots)
+		})
+	}
+
+	for i, values := range rows {
+		row := maps.MapStrAny{}
+		for cid, name := range columns {
+			row[name] = values[cid]
+		}
+
+		id, err := mod.newQuery().
+			Table(mod.MetaData.Table.Name).
+			InsertGetID(row)
+		if err != nil {
+			return err
+		}
 
+		key := snapshots[i].Get(mod.PrimaryKey)
+		if key == nil {
+			key = id
+		}
+		mod.audit(AuditInsert, key, nil, snapshots[i])
+	}
+	return nil
 }
 
 // MustInsert 插入多条数据, 失败抛出异常
@@ -324,7 +484,7 @@ func (mod *Model) MustInsert(columns []string, rows [][]interface{}) {
 // UpdateWhere 按条件更新记录, 返回更新行数
 func (mod *Model) UpdateWhere(param QueryParam, row maps.MapStrAny) (int, error) {
 
-	errs := mod.Validate(row) // 输入数据校验
+	errs := mod.validate(validationContext{mod: mod, row: row, bulk: true}) // 输入数据校验 (匹配的多条记录不校验唯一值)
 	if len(errs) > 0 {
 		msgs := []string{}
 		for _, err := range errs {
@@ -334,12 +494,24 @@ func (mod *Model) UpdateWhere(param QueryParam, row maps.MapStrAny) (int, error)
 		exception.New("输入参数错误\n %s", 400, strings.Join(msgs, "\n")).Ctx(errs).Throw()
 	}
 
+	snapshot := mod.auditSnapshot(row)
+	olds := mod.auditFind(param)
 	mod.FliterIn(row) // 入库前输入数据预处理
+	mod.unsetTenant(row)
+	matched := param
+	version, row := mod.versionOf(row)
+	if version != nil {
+		param.Wheres = append(append([]QueryWhere{}, param.Wheres...), QueryWhere{Column: VersionColumn, Value: version, internal: true})
+	}
 
 	if mod.MetaData.Option.Timestamps {
 		row.Set("updated_at", dbal.Raw("CURRENT_TIMESTAMP"))
 	}
 
+	if mod.MetaData.Option.Trackings {
+		mod.setTracking(row, "updated_by")
+	}
+
 	// 如果不是 SQLite3 添加字段
 	if mod.Driver != "sqlite3" {
 		for name, value := range row {
@@ -352,6 +524,7 @@ func (mod *Model) UpdateWhere(param QueryParam, row maps.MapStrAny) (int, error)
 	}
 
 	param.Model = mod.Name
+	param.bind(mod)
 	stack := NewQueryStack(param)
 	qb := stack.FirstQuery()
 	effect, err := qb.Update(row)
@@ -359,6 +532,11 @@ func (mod *Model) UpdateWhere(param QueryParam, row maps.MapStrAny) (int, error)
 		return 0, err
 	}
 
+	if effect == 0 && version != nil {
+		mod.versionConflictWhere(matched, version)
+	}
+
+	mod.auditRows(AuditUpdate, olds, snapshot)
 	return int(effect), err
 }
 
@@ -372,11 +550,18 @@ func (mod *Model) MustUpdateWhere(param QueryParam, row maps.MapStrAny) int {
 }
 
 // DeleteWhere 批量删除数据, 返回更新行数
-func (mod *Model) DeleteWhere(param QueryParam) (int, error) {
+func (mod *Model) DeleteWhere(param QueryParam) (effect int, err error) {
 
 	// 软删除
 	if mod.MetaData.Option.SoftDeletes {
 
+		olds := mod.auditFind(param)
+		defer func() {
+			if err == nil {
+				mod.auditRows(AuditDelete, olds, nil)
+			}
+		}()
+
 		// 兼容 SQLite3
 		if mod.Driver == "sqlite3" {
 			return mod.sqlite3DeleteWhere(param)
@@ -404,6 +589,7 @@ func (mod *Model) DeleteWhere(param QueryParam) (int, error) {
 		}
 
 		param.Model = mod.Name
+		param.bind(mod)
 		stack := NewQueryStack(param)
 		qb := stack.FirstQuery()
 
@@ -420,6 +606,9 @@ func (mod *Model) DeleteWhere(param QueryParam) (int, error) {
 		field := fmt.Sprintf("%s.%s", mod.MetaData.Table.Name, "deleted_at")
 		// data["deleted_at"] = dbal.Raw("CURRENT_TIMESTAMP")
 		data[field] = dbal.Raw("CURRENT_TIMESTAMP")
+		if user := mod.trackingUser(); user != nil {
+			data[fmt.Sprintf("%s.%s", mod.MetaData.Table.Name, "deleted_by")] = user
+		}
 		effect, err := qb.Update(data)
 		if err != nil {
 			return 0, err
@@ -434,6 +623,7 @@ func (mod *Model) DeleteWhere(param QueryParam) (int, error) {
 func (mod *Model) sqlite3DeleteWhere(param QueryParam) (int, error) {
 	data := maps.MapStrAny{}
 	param.Model = mod.Name
+	param.bind(mod)
 	stack := NewQueryStack(param)
 	qb := stack.FirstQuery()
 
@@ -441,6 +631,9 @@ func (mod *Model) sqlite3DeleteWhere(param QueryParam) (int, error) {
 	// field := fmt.Sprintf("%s.%s", mod.MetaData.Table.Name, "deleted_at")
 	// data[field] = dbal.Raw("CURRENT_TIMESTAMP")
 	data["deleted_at"] = dbal.Raw("CURRENT_TIMESTAMP")
+	if user := mod.trackingUser(); user != nil {
+		data["deleted_by"] = user
+	}
 	for _, col := range mod.UniqueColumns {
 		typ := strings.ToLower(col.Type)
 		if typ == "string" {
@@ -466,15 +659,19 @@ func (mod *Model) MustDeleteWhere(param QueryParam) int {
 
 // DestroyWhere 批量真删除数据, 返回更新行数
 func (mod *Model) DestroyWhere(param QueryParam) (int, error) {
+	olds := mod.auditFind(param)
 	param.Model = mod.Name
-	qb := capsule.Query().Table(mod.MetaData.Table.Name)
+	param.bind(mod)
+	qb := mod.newQuery().Table(mod.MetaData.Table.Name)
 	for _, where := range param.Wheres {
 		param.Where(where, qb, mod)
 	}
+	mod.tenantWhere(qb)
 	effect, err := qb.Delete()
 	if err != nil {
 		return 0, err
 	}
+	mod.auditRows(AuditDelete, olds, nil)
 	return int(effect), nil
 }
 
@@ -505,7 +702,7 @@ func (mod *Model) EachSave(rows []map[string]interface{}, eachrow ...maps.MapStr
 
 		// check primary
 		if id, has := row[mod.PrimaryKey]; has {
-			_, err := mod.Find(id, QueryParam{Select: []interface{}{mod.PrimaryKey}})
+			_, err := mod.find(id, QueryParam{Select: []interface{}{mod.PrimaryKey}})
 			if err != nil { // id does not exists & create
 				_, err := mod.Create(row)
 				if err != nil {
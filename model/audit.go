@@ -0,0 +1,346 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/yaoapp/gou/store"
+	"github.com/yaoapp/kun/log"
+	"github.com/yaoapp/kun/maps"
+)
+
+// The audit actions
+const (
+	AuditInsert = "insert"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditLog the audit log of a row change
+type AuditLog struct {
+	Model   string                 `json:"model"`
+	Key     interface{}            `json:"key,omitempty"`
+	Action  string                 `json:"action"`
+	Changes map[string]AuditChange `json:"changes,omitempty"`
+	User    interface{}            `json:"user,omitempty"`
+	Time    string                 `json:"time"`
+	tx      *Tx                    // the transaction of the changed row, the log is written in it if the auditor supports
+}
+
+// AuditChange the old and new values of the changed column
+type AuditChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Auditor the audit log writer, the audit logs could be written to a model or a store
+type Auditor interface {
+	Write(log AuditLog) error
+	History(model string, key interface{}) ([]AuditLog, error)
+}
+
+// Audit the audit log writer of the models with the Logging option
+var Audit Auditor = nil
+
+// SetAuditor set the audit log writer
+func SetAuditor(auditor Auditor) {
+	Audit = auditor
+}
+
+// auditModelSource the source of the built-in audit model
+const auditModelSource = `{
+	"name": "Audit Logs",
+	"table": { "name": "%s" },
+	"columns": [
+		{ "name": "id", "type": "ID" },
+		{ "name": "model", "type": "string", "length": 200, "index": true },
+		{ "name": "record", "type": "string", "length": 200, "index": true, "nullable": true },
+		{ "name": "action", "type": "string", "length": 20 },
+		{ "name": "changes", "type": "json", "nullable": true },
+		{ "name": "operator", "type": "json", "nullable": true },
+		{ "name": "time", "type": "string", "length": 50, "index": true }
+	]
+}`
+
+// LoadAuditModel load the built-in audit model, it should be migrated before using it
+func LoadAuditModel(id string, table string) (*Model, error) {
+	return LoadSource([]byte(fmt.Sprintf(auditModelSource, table)), fmt.Sprintf("%s.mod.json", id), id)
+}
+
+// ModelAuditor write the audit logs to the model
+type ModelAuditor struct{ Model string }
+
+// StoreAuditor write the audit logs to the store, the logs of a row are kept as a list.
+// Limit is the max number of the logs of a row, the oldest logs are dropped (default is AuditStoreLimit)
+type StoreAuditor struct {
+	Store store.Store
+	Limit int
+}
+
+// AuditStoreLimit the default max number of the logs of a row in the store
+var AuditStoreLimit = 500
+
+// auditStoreMutex serialize the read-modify-write of the logs in the store (in the current process)
+var auditStoreMutex sync.Mutex
+
+// Write the audit log to the model, the log is written in the transaction of the changed row
+func (auditor ModelAuditor) Write(log AuditLog) error {
+	_, err := Select(auditor.Model).withTx(log.tx).Create(maps.MapStrAny{
+		"model":    log.Model,
+		"record":   auditKey(log.Key),
+		"action":   log.Action,
+		"changes":  log.Changes,
+		"operator": log.User,
+		"time":     log.Time,
+	})
+	return err
+}
+
+// History read the audit logs of the row from the model
+func (auditor ModelAuditor) History(model string, key interface{}) ([]AuditLog, error) {
+	rows, err := Select(auditor.Model).Get(QueryParam{
+		Wheres: []QueryWhere{{Column: "model", Value: model, internal: true}, {Column: "record", Value: auditKey(key), internal: true}},
+		Orders: []QueryOrder{{Column: "id", Option: "asc"}},
+		Limit:  math.MaxInt32,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logs := []AuditLog{}
+	for _, row := range rows {
+		changes := map[string]AuditChange{}
+		if value := row.Get("changes"); value != nil {
+			bytes, err := jsoniter.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			err = jsoniter.Unmarshal(bytes, &changes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		logs = append(logs, AuditLog{
+			Model:   fmt.Sprintf("%v", row.Get("model")),
+			Key:     row.Get("record"),
+			Action:  fmt.Sprintf("%v", row.Get("action")),
+			Changes: changes,
+			User:    row.Get("operator"),
+			Time:    fmt.Sprintf("%v", row.Get("time")),
+		})
+	}
+	return logs, nil
+}
+
+// Write the audit log to the store
+func (auditor StoreAuditor) Write(log AuditLog) error {
+	auditStoreMutex.Lock()
+	defer auditStoreMutex.Unlock()
+
+	id := fmt.Sprintf("audit:%s:%s", log.Model, auditKey(log.Key))
+	logs, err := auditor.History(log.Model, log.Key)
+	if err != nil {
+		return err
+	}
+
+	limit := auditor.Limit
+	if limit <= 0 {
+		limit = AuditStoreLimit
+	}
+
+	logs = append(logs, log)
+	if len(logs) > limit {
+		logs = logs[len(logs)-limit:]
+	}
+
+	text, err := jsoniter.MarshalToString(logs)
+	if err != nil {
+		return err
+	}
+	return auditor.Store.Set(id, text, 0)
+}
+
+// History read the audit logs of the row from the store
+func (auditor StoreAuditor) History(model string, key interface{}) ([]AuditLog, error) {
+	logs := []AuditLog{}
+	value, ok := auditor.Store.Get(fmt.Sprintf("audit:%s:%s", model, auditKey(key)))
+	if !ok {
+		return logs, nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("the audit logs of %s %v are not valid", model, key)
+	}
+
+	err := jsoniter.UnmarshalFromString(text, &logs)
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// errHistoryNotFound the row is not found in the tenant of the caller
+var errHistoryNotFound = errors.New("the row is not found")
+
+// History read the audit logs of the row, the changes of the columns which are not readable by the caller are stripped.
+// The row should belong to the tenant of the scoped caller (the trashed rows are included).
+func (mod *Model) History(id interface{}) ([]AuditLog, error) {
+	if Audit == nil {
+		return nil, fmt.Errorf("the auditor is not set")
+	}
+
+	if _, scoped := mod.tenant(); scoped {
+		qb := mod.newQuery().Table(mod.MetaData.Table.Name).Where(mod.PrimaryKey, id)
+		mod.tenantWhere(qb)
+		cnt, err := qb.Count()
+		if err != nil {
+			return nil, err
+		}
+		if cnt == 0 {
+			return nil, fmt.Errorf("%s %v: %w", mod.ID, id, errHistoryNotFound)
+		}
+	}
+
+	logs, err := Audit.History(mod.ID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	acl := mod.acl()
+	if acl == nil {
+		return logs, nil
+	}
+
+	for i := range logs {
+		changes := map[string]AuditChange{}
+		for name, change := range logs[i].Changes {
+			if acl.readable(name) {
+				changes[name] = change
+			}
+		}
+		logs[i].Changes = changes
+	}
+	return logs, nil
+}
+
+// auditSnapshot copy the input row before it is filtered, return nil if the logging option is off
+func (mod *Model) auditSnapshot(row maps.MapStrAny) maps.MapStrAny {
+	if !mod.MetaData.Option.Logging {
+		return nil
+	}
+
+	snapshot := maps.MapStrAny{}
+	for name, value := range row {
+		if _, has := mod.Columns[name]; has {
+			snapshot[name] = value
+		}
+	}
+	return snapshot
+}
+
+// auditFind read the rows before updating or deleting them, return nil if the logging option is off
+func (mod *Model) auditFind(param QueryParam) []maps.MapStr {
+	if !mod.MetaData.Option.Logging {
+		return nil
+	}
+
+	param.Model = mod.Name
+	param.bind(mod)
+	param.Select = nil
+	param.Withs = nil
+	param.Orders = nil
+	if param.Limit <= 0 {
+		param.Limit = math.MaxInt32
+	}
+	return NewQueryStack(param).Run()
+}
+
+// auditRows write the audit logs of the rows
+func (mod *Model) auditRows(action string, rows []maps.MapStr, new maps.MapStrAny) {
+	for _, row := range rows {
+		mod.audit(action, row.Get(mod.PrimaryKey), row, new)
+	}
+}
+
+// audit write the audit log, the errors are logged only
+func (mod *Model) audit(action string, key interface{}, old maps.MapStrAny, new maps.MapStrAny) {
+	if !mod.MetaData.Option.Logging {
+		return
+	}
+
+	if Audit == nil {
+		log.Warn("[Model] %s %s %v: the auditor is not set", mod.ID, action, key)
+		return
+	}
+
+	changes := map[string]AuditChange{}
+	switch action {
+	case AuditInsert:
+		for name, value := range new {
+			changes[name] = AuditChange{New: mod.auditValue(name, value)}
+		}
+
+	case AuditUpdate:
+		for name, value := range new {
+			if name == mod.PrimaryKey {
+				continue
+			}
+			prev := old.Get(name)
+			if auditEqual(prev, value) {
+				continue
+			}
+			changes[name] = AuditChange{Old: mod.auditValue(name, prev), New: mod.auditValue(name, value)}
+		}
+		if len(changes) == 0 {
+			return
+		}
+
+	case AuditDelete:
+		for name, value := range old {
+			if _, has := mod.Columns[name]; has {
+				changes[name] = AuditChange{Old: mod.auditValue(name, value)}
+			}
+		}
+	}
+
+	err := Audit.Write(AuditLog{
+		Model:   mod.ID,
+		Key:     key,
+		Action:  action,
+		Changes: changes,
+		User:    mod.sessionUser(),
+		Time:    time.Now().Format(time.RFC3339Nano),
+		tx:      mod.tx,
+	})
+
+	if err != nil {
+		log.Error("[Model] %s %s %v audit: %s", mod.ID, action, key, err.Error())
+	}
+}
+
+// auditValue mask the value of the encrypted column
+func (mod *Model) auditValue(name string, value interface{}) interface{} {
+	if column, has := mod.Columns[name]; has && column.Crypt != "" && value != nil {
+		return "******"
+	}
+	return value
+}
+
+func auditEqual(a, b interface{}) bool {
+	textA, errA := jsoniter.MarshalToString(a)
+	textB, errB := jsoniter.MarshalToString(b)
+	return errA == nil && errB == nil && textA == textB
+}
+
+func auditKey(key interface{}) string {
+	if key == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", key)
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/gou/session"
+	"github.com/yaoapp/gou/store"
+	"github.com/yaoapp/kun/any"
+)
+
+func TestAuditModel(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	defer SetAuditor(nil)
+
+	logs, err := LoadAuditModel("tests.audit.logs", "tests_audit_logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = logs.Migrate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetAuditor(ModelAuditor{Model: "tests.audit.logs"})
+	prepareTests(t, "tests.audit")
+
+	sid := session.ID()
+	session.Global().ID(sid).Set(TrackingSessionKey, 10)
+
+	id := process.New("models.tests.audit.Create", map[string]interface{}{"name": "foo", "secret": "123456"}).WithSID(sid).Run()
+	process.New("models.tests.audit.Save", map[string]interface{}{"id": id, "name": "bar"}).WithSID(sid).Run()
+	process.New("models.tests.audit.Delete", id).WithSID(sid).Run()
+
+	history, ok := process.New("models.tests.audit.History", id).Run().([]AuditLog)
+	if !ok {
+		t.Fatal("the history is not a list of the audit logs")
+	}
+
+	assert.Len(t, history, 3)
+	assert.Equal(t, AuditInsert, history[0].Action)
+	assert.Equal(t, "foo", history[0].Changes["name"].New)
+	assert.Equal(t, "******", history[0].Changes["secret"].New)
+	assert.Equal(t, 10, any.Of(history[0].User).CInt())
+
+	assert.Equal(t, AuditUpdate, history[1].Action)
+	assert.Equal(t, "foo", history[1].Changes["name"].Old)
+	assert.Equal(t, "bar", history[1].Changes["name"].New)
+	assert.Len(t, history[1].Changes, 1)
+
+	assert.Equal(t, AuditDelete, history[2].Action)
+	assert.Equal(t, "bar", history[2].Changes["name"].Old)
+	assert.Equal(t, any.Of(id).CInt(), any.Of(history[2].Key).CInt())
+}
+
+func TestAuditStore(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	defer SetAuditor(nil)
+
+	lru, err := store.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetAuditor(StoreAuditor{Store: lru})
+	mod := prepareTests(t, "tests.audit")
+
+	id := mod.MustCreate(map[string]interface{}{"name": "foo"})
+	mod.MustUpdate(id, map[string]interface{}{"name": "foo"}) // Nothing changed
+	mod.MustUpdate(id, map[string]interface{}{"name": "bar"})
+
+	history, err := mod.History(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, history, 2)
+	assert.Equal(t, AuditInsert, history[0].Action)
+	assert.Equal(t, AuditUpdate, history[1].Action)
+	assert.Equal(t, "bar", history[1].Changes["name"].New)
+	assert.Nil(t, history[1].User)
+}
+
+func TestAuditInsert(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	defer SetAuditor(nil)
+
+	lru, err := store.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetAuditor(StoreAuditor{Store: lru, Limit: 2})
+	mod := prepareTests(t, "tests.audit")
+
+	// the auto-increment keys are resolved
+	mod.MustInsert([]string{"name"}, [][]interface{}{{"foo"}, {"bar"}})
+	rows := mod.MustGet(QueryParam{Orders: []QueryOrder{{Column: "id"}}})
+	assert.Len(t, rows, 2)
+
+	history, err := mod.History(rows[1].Get("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, history, 1)
+	assert.Equal(t, AuditInsert, history[0].Action)
+	assert.Equal(t, "bar", history[0].Changes["name"].New)
+
+	// the oldest logs are dropped
+	id := rows[0].Get("id")
+	mod.MustUpdate(id, map[string]interface{}{"name": "foo-1"})
+	mod.MustUpdate(id, map[string]interface{}{"name": "foo-2"})
+	history, err = mod.History(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, history, 2)
+	assert.Equal(t, "foo-2", history[1].Changes["name"].New)
+}
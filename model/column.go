@@ -7,14 +7,18 @@ import (
 	"github.com/yaoapp/kun/day"
 	"github.com/yaoapp/kun/exception"
 	"github.com/yaoapp/kun/maps"
-	"github.com/yaoapp/kun/str"
 	"github.com/yaoapp/xun/dbal"
 	"github.com/yaoapp/xun/dbal/schema"
 )
 
 // FliterIn 输入过滤器
 func (column *Column) FliterIn(value interface{}, row maps.MapStrAny) {
-	column.fliterInCrypt(value, row)
+	column.fliterIn(column.model, value, row)
+}
+
+// fliterIn 输入过滤器 (mod 为调用方模型, 含会话及全局变量)
+func (column *Column) fliterIn(mod *Model, value interface{}, row maps.MapStrAny) {
+	column.fliterInCrypt(mod, value, row)
 	column.fliterInJSON(value, row)
 	column.fliterInDateTime(value, row)
 }
@@ -57,7 +61,7 @@ func (column *Column) fliterOutJSON(value interface{}, row maps.MapStrAny, expor
 }
 
 // fliterInCrypt 加密字段处理
-func (column *Column) fliterInCrypt(value interface{}, row maps.MapStrAny) {
+func (column *Column) fliterInCrypt(mod *Model, value interface{}, row maps.MapStrAny) {
 	if column.Crypt == "" {
 		return
 	}
@@ -78,7 +82,7 @@ func (column *Column) fliterInCrypt(value interface{}, row maps.MapStrAny) {
 	}
 
 	// 忽略除 MySQL 之外的 AES 驱动
-	if column.Crypt == "AES" && column.model.Driver != "mysql" {
+	if column.Crypt == "AES" && mod.Driver != "mysql" {
 		column.Crypt = ""
 		return
 	}
@@ -125,24 +129,13 @@ func (column *Column) fliterInJSON(value interface{}, row maps.MapStrAny) {
 	row.Set(column.Name, string(bytes))
 }
 
-// Validate 数值有效性验证
-func (column *Column) Validate(value interface{}, row maps.MapStrAny) (bool, []string) {
-	messages := []string{}
-	success := true
-	for _, v := range column.Validations {
-		method, has := Validations[v.Method]
-		if !has {
-			continue
-		}
-		if !method(value, row, v.Args...) {
-			data := column.Map()
-			data["input"] = value
-			message := str.Bind(v.Message, data)
-			messages = append(messages, message)
-			success = false
-		}
+// Validate 数值有效性验证, mod 为调用方模型 (含会话及全局变量), 默认为字段所属模型
+func (column *Column) Validate(value interface{}, row maps.MapStrAny, mod ...*Model) (bool, []string) {
+	ctx := validationContext{mod: column.model, column: column, row: row}
+	if len(mod) > 0 && mod[0] != nil {
+		ctx.mod = mod[0]
 	}
-	return success, messages
+	return column.validate(ctx, value)
 }
 
 // Map 转换为Map
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/yaoapp/gou/connector"
+	"github.com/yaoapp/gou/connector/database"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/xun/capsule"
+	"github.com/yaoapp/xun/dbal/query"
+)
+
+// manager get the database manager of the model connector, the global manager is used if the connector is not set
+func (mod *Model) manager() *capsule.Manager {
+	manager, err := connectorManager(mod.MetaData.Connector)
+	if err != nil {
+		exception.New("model %s: %s", 500, mod.ID, err.Error()).Throw()
+	}
+	return manager
+}
+
+// newQuery create a new query builder with the model connector, the builder runs in the transaction if the model is bound to one
+func (mod *Model) newQuery() query.Query {
+	if mod.tx != nil {
+		return mod.tx.qb.New()
+	}
+	return mod.manager().Query()
+}
+
+// sameConnector check if the models are on the same connector, the relations across connectors could not be joined
+func (mod *Model) sameConnector(other *Model) bool {
+	return ConnectorName(mod.MetaData.Connector) == ConnectorName(other.MetaData.Connector)
+}
+
+// joinable throw a 400 exception if the related model is on the other connector,
+// the relation could not be joined (hasOneThrough, the where and order clauses) or filtered with the exists sub query.
+func (mod *Model) joinable(name string, rel *Model) {
+	if !mod.sameConnector(rel) {
+		exception.New("%s: the relation %s is on the connector %s, it could not be used across the connectors", 400, mod.ID, name, ConnectorName(rel.MetaData.Connector)).Throw()
+	}
+}
+
+// connectorManager get the database manager of the connector
+func connectorManager(name string) (*capsule.Manager, error) {
+	if ConnectorName(name) == "default" {
+		if capsule.Global == nil {
+			return nil, fmt.Errorf("the default database connection is not set")
+		}
+		return capsule.Global, nil
+	}
+
+	c, err := connector.Select(name)
+	if err != nil {
+		return nil, err
+	}
+
+	db, ok := c.(*database.Xun)
+	if !ok || db.Manager == nil {
+		return nil, fmt.Errorf("connector %s is not a database connector", name)
+	}
+	return db.Manager, nil
+}
+
+// ConnectorName the name of the connector, the empty name is the default connector
+func ConnectorName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
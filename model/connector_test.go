@@ -0,0 +1,66 @@
+package model
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/connector"
+	"github.com/yaoapp/gou/connector/database"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/capsule"
+)
+
+func TestConnector(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+
+	manager := capsule.New()
+	_, err := manager.Add("primary", "sqlite3", filepath.Join(t.TempDir(), "second.db"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connector.Connectors["tests.second"] = &database.Xun{Manager: manager, Driver: "sqlite3"}
+	defer delete(connector.Connectors, "tests.second")
+
+	author := prepareTests(t, "tests.conn.author")
+	book := prepareTests(t, "tests.conn.book")
+	assert.Equal(t, "sqlite3", author.Driver)
+
+	has, err := manager.Schema().HasTable("tests_conn_author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, has)
+
+	id := author.MustCreate(maps.MapStrAny{"name": "Tolkien"})
+	book.MustCreate(maps.MapStrAny{"title": "The Hobbit", "author_id": id})
+	book.MustCreate(maps.MapStrAny{"title": "Anonymous"})
+
+	row := author.MustFind(id, QueryParam{})
+	assert.Equal(t, "Tolkien", row.Get("name"))
+
+	books := book.MustGet(QueryParam{
+		Orders: []QueryOrder{{Column: "id"}},
+		Withs:  map[string]With{"author": {}},
+	})
+	assert.Len(t, books, 2)
+	assert.Equal(t, "Tolkien", any.Of(books[0].Get("author")).MapStr().Get("name"))
+	assert.Nil(t, books[1].Get("author"))
+
+	authors := author.MustGet(QueryParam{Withs: map[string]With{"books": {}}})
+	assert.Len(t, authors, 1)
+	assert.Len(t, authors[0].Get("books"), 1)
+
+	// the relations could not be joined or filtered across the connectors
+	assert.Panics(t, func() {
+		book.MustGet(QueryParam{Wheres: []QueryWhere{{Rel: "author", Column: "name", Value: "Tolkien"}}})
+	})
+	assert.Panics(t, func() {
+		book.MustGet(QueryParam{Orders: []QueryOrder{{Rel: "author", Column: "name"}}})
+	})
+	assert.Panics(t, func() {
+		author.MustGet(QueryParam{Wheres: []QueryWhere{{Rel: "books", Column: "title", Value: "The Hobbit"}}})
+	})
+}
@@ -0,0 +1,266 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/dbal/query"
+)
+
+// cursorValue the decoded cursor, the values of the order columns of the last row
+type cursorValue struct {
+	Key    string        `json:"k"`
+	Values []interface{} `json:"v"`
+}
+
+// Cursor 游标分页查询 (keyset pagination), after 为上一页返回的 next 游标, 首页为空
+func (mod *Model) Cursor(param QueryParam, after string, limit int) (maps.MapStr, error) {
+	param.Model = mod.Name
+	param.bind(mod)
+	stack := NewQueryStack(param)
+	return stack.Cursor(after, limit)
+}
+
+// MustCursor 游标分页查询, 失败抛出异常
+func (mod *Model) MustCursor(param QueryParam, after string, limit int) maps.MapStr {
+	res, err := mod.Cursor(param, after, limit)
+	if err != nil {
+		exception.Err(err, 400).Throw()
+	}
+	return res
+}
+
+// EachCursor 按游标逐页读取全部数据, handler 返回错误时停止
+func (mod *Model) EachCursor(param QueryParam, limit int, handler func(rows []maps.MapStr) error) error {
+	after := ""
+	for {
+		res, err := mod.Cursor(param, after, limit)
+		if err != nil {
+			return err
+		}
+
+		rows, _ := res["data"].([]maps.MapStr)
+		if len(rows) > 0 {
+			err = handler(rows)
+			if err != nil {
+				return err
+			}
+		}
+
+		after, _ = res["next"].(string)
+		if after == "" {
+			return nil
+		}
+	}
+}
+
+// Cursor 执行查询栈(游标分页), 按排序字段与主键生成游标
+func (stack *QueryStack) Cursor(after string, limit int) (maps.MapStrAny, error) {
+	if len(stack.Builders) == 0 {
+		return nil, fmt.Errorf("the query stack is empty")
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	builder := stack.Builders[0]
+	param := stack.Params[0].QueryParam
+	orders, err := param.cursorOrders(builder.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	// 排序字段
+	key := []string{}
+	for _, order := range orders {
+		key = append(key, order.Column+" "+order.Option)
+	}
+
+	qb := builder.Query
+	last := orders[len(orders)-1]
+	if !param.hasOrder(last.Column) {
+		qb.OrderBy(builder.Model.FliterWhere(param.Alias, last.Column), last.Option)
+	}
+
+	for _, order := range orders {
+		if !param.hasSelectColumn(order.Column) && len(param.Select) > 0 {
+			selects := builder.Model.Filterselect(param.Alias, []interface{}{order.Column}, builder.ColumnMap, "")
+			qb.SelectAppend(selects...)
+		}
+	}
+
+	// 游标条件
+	if after != "" {
+		value, err := cursorDecode(after)
+		if err != nil {
+			return nil, err
+		}
+
+		if value.Key != strings.Join(key, ",") || len(value.Values) != len(orders) {
+			return nil, fmt.Errorf("the cursor does not match the orders of the query")
+		}
+		param.cursorWhere(qb, builder.Model, orders, value.Values)
+	}
+
+	// 多读取一条, 判断是否有下一页
+	stack.Params[0].QueryParam.Limit = limit + 1
+	rows := stack.Run()
+
+	next := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		values := []interface{}{}
+		for _, order := range orders {
+			value := rows[limit-1].Get(order.Column)
+			if t, ok := value.(time.Time); ok {
+				value = t.Format("2006-01-02 15:04:05.999999")
+			}
+			values = append(values, value)
+		}
+
+		next, err = cursorEncode(cursorValue{Key: strings.Join(key, ","), Values: values})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return maps.MapStrAny{
+		"data":     rows,
+		"pagesize": limit,
+		"next":     next,
+	}, nil
+}
+
+// cursorOrders the order columns of the cursor, the primary key is added as the last one
+func (param QueryParam) cursorOrders(mod *Model) ([]QueryOrder, error) {
+	orders := []QueryOrder{}
+	for _, order := range param.Orders {
+		if order.Rel != "" {
+			return nil, fmt.Errorf("the relation order %s.%s is not supported by the cursor", order.Rel, order.Column)
+		}
+
+		if _, has := mod.Columns[order.Column]; !has {
+			return nil, fmt.Errorf("the order column %s does not exist", order.Column)
+		}
+
+		// the values of the order columns are encoded in the cursor
+		mod.checkReadable(mod.acl(), order.Column)
+
+		option := strings.ToLower(order.Option)
+		if option != "desc" {
+			option = "asc"
+		}
+		orders = append(orders, QueryOrder{Column: order.Column, Option: option})
+	}
+
+	if !param.hasOrder(mod.PrimaryKey) {
+		option := "asc"
+		if len(orders) > 0 {
+			option = orders[len(orders)-1].Option
+		}
+		orders = append(orders, QueryOrder{Column: mod.PrimaryKey, Option: option})
+	}
+	return orders, nil
+}
+
+// cursorWhere the keyset condition: (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ...
+// the null values of the nullable columns are placed as the driver sorts them (see cursorNullsFirst)
+func (param QueryParam) cursorWhere(qb query.Query, mod *Model, orders []QueryOrder, values []interface{}) {
+	qb.Where(func(qb query.Query) {
+		for i := range orders {
+			column := mod.FliterWhere(param.Alias, orders[i].Column)
+			nullsFirst := mod.cursorNullsFirst(orders[i].Option)
+
+			// the null is the last value, no rows follow it in the column
+			if values[i] == nil && !nullsFirst {
+				continue
+			}
+
+			qb.OrWhere(func(sub query.Query) {
+				for j := 0; j < i; j++ {
+					if values[j] == nil {
+						sub.WhereNull(mod.FliterWhere(param.Alias, orders[j].Column))
+						continue
+					}
+					sub.Where(mod.FliterWhere(param.Alias, orders[j].Column), "=", values[j])
+				}
+
+				// all the values follow the null
+				if values[i] == nil {
+					sub.WhereNotNull(column)
+					return
+				}
+
+				op := ">"
+				if orders[i].Option == "desc" {
+					op = "<"
+				}
+
+				if nullsFirst {
+					sub.Where(column, op, values[i])
+					return
+				}
+
+				// the nulls follow the value
+				sub.Where(func(next query.Query) {
+					next.Where(column, op, values[i]).OrWhereNull(column)
+				})
+			})
+		}
+	})
+}
+
+// cursorNullsFirst the null values are sorted first: MySQL and SQLite sort them as the smallest values, PostgreSQL as the largest ones
+func (mod *Model) cursorNullsFirst(option string) bool {
+	if mod.Driver == "postgres" {
+		return option == "desc"
+	}
+	return option != "desc"
+}
+
+// hasOrder 检查排序字段是否存在
+func (param QueryParam) hasOrder(column string) bool {
+	for _, order := range param.Orders {
+		if order.Rel == "" && order.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+func cursorEncode(value cursorValue) (string, error) {
+	bytes, err := jsoniter.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func cursorDecode(cursor string) (cursorValue, error) {
+	value := cursorValue{}
+	bytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return value, fmt.Errorf("the cursor is invalid")
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(bytes)))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	if err != nil {
+		return value, fmt.Errorf("the cursor is invalid")
+	}
+
+	for i, v := range value.Values {
+		if number, ok := v.(json.Number); ok {
+			value.Values[i] = number.String()
+		}
+	}
+	return value, nil
+}
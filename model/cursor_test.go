@@ -0,0 +1,102 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestCursor(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.cursor", 10)
+
+	// score desc, id desc
+	param := QueryParam{Orders: []QueryOrder{{Column: "score", Option: "desc"}}}
+	names := []string{}
+	after := ""
+	pages := 0
+	for {
+		res := mod.MustCursor(param, after, 4)
+		for _, row := range res["data"].([]maps.MapStr) {
+			names = append(names, row.Get("name").(string))
+		}
+		pages++
+		after = res["next"].(string)
+		if after == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"user-9", "user-8", "user-7", "user-6", "user-5", "user-4", "user-3", "user-2", "user-1", "user-0"}, names)
+
+	// The rows inserted before the cursor are not repeated
+	res := mod.MustCursor(param, "", 3)
+	mod.MustCreate(maps.MapStrAny{"name": "user-top", "score": 100})
+	res = mod.MustCursor(param, res["next"].(string), 3)
+	assert.Equal(t, "user-6", res["data"].([]maps.MapStr)[0].Get("name"))
+
+	// The cursor does not match the orders
+	assert.Panics(t, func() { mod.MustCursor(QueryParam{}, res["next"].(string), 3) })
+}
+
+func TestCursorNull(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.cursor", 10)
+	mod.MustUpdateWhere(QueryParam{Wheres: []QueryWhere{{Column: "score", OP: "lt", Value: 4}}}, maps.MapStrAny{"remark": "a"})
+	mod.MustUpdateWhere(QueryParam{Wheres: []QueryWhere{{Column: "score", OP: "gt", Value: 7}}}, maps.MapStrAny{"remark": "b"})
+
+	// the rows with the null remark are not skipped or repeated at the page boundaries
+	for _, option := range []string{"asc", "desc"} {
+		param := QueryParam{Orders: []QueryOrder{{Column: "remark", Option: option}}}
+		names := map[string]bool{}
+		err := mod.EachCursor(param, 3, func(rows []maps.MapStr) error {
+			for _, row := range rows {
+				names[row.Get("name").(string)] = true
+			}
+			return nil
+		})
+		assert.Nil(t, err, option)
+		assert.Len(t, names, 10, option)
+
+		cnt := 0
+		mod.EachCursor(param, 3, func(rows []maps.MapStr) error {
+			cnt = cnt + len(rows)
+			return nil
+		})
+		assert.Equal(t, 10, cnt, option)
+	}
+}
+
+func TestCursorProcess(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.cursor", 10)
+
+	cnt := 0
+	err := mod.EachCursor(QueryParam{Select: []interface{}{"name"}}, 3, func(rows []maps.MapStr) error {
+		cnt = cnt + len(rows)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 10, cnt)
+
+	res := process.New("models.tests.cursor.Cursor", map[string]interface{}{
+		"wheres": []map[string]interface{}{{"column": "score", "op": "ge", "value": 5}},
+	}, nil, 3).Run().(maps.MapStr)
+	rows := res["data"].([]maps.MapStr)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, 5, any.Of(rows[0].Get("score")).CInt())
+
+	res = process.New("models.tests.cursor.Cursor", map[string]interface{}{
+		"wheres": []map[string]interface{}{{"column": "score", "op": "ge", "value": 5}},
+	}, res["next"], 3).Run().(maps.MapStr)
+	rows = res["data"].([]maps.MapStr)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "", res["next"])
+}
@@ -0,0 +1,658 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/yaoapp/gou/encoding/xlsx"
+	"github.com/yaoapp/gou/fs"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+)
+
+// The exchange file formats
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+// ExchangeColumn the column mapping of the exchange file, Title is the header of the file (default the column name)
+type ExchangeColumn struct {
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+}
+
+// ExportOption the option of exporting the rows to a file
+type ExportOption struct {
+	FS        string           `json:"fs,omitempty"`     // the filesystem name, used by the process only (default system)
+	Format    string           `json:"format,omitempty"` // csv, xlsx, ndjson (default the file extension)
+	Columns   []ExchangeColumn `json:"columns,omitempty"`
+	Query     QueryParam       `json:"query,omitempty"`
+	ChunkSize int              `json:"chunk_size,omitempty"`
+}
+
+// ImportOption the option of importing the rows from a file
+type ImportOption struct {
+	FS      string           `json:"fs,omitempty"`     // the filesystem name, used by the process only (default system)
+	Format  string           `json:"format,omitempty"` // csv, xlsx, ndjson (default the file extension)
+	Columns []ExchangeColumn `json:"columns,omitempty"`
+	Upsert  string           `json:"upsert,omitempty"` // the unique column, update the row if the value exists
+}
+
+// ImportResult the report of importing, the Line of the errors is the line number of the file
+type ImportResult struct {
+	Total   int                `json:"total"`
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Failed  int                `json:"failed"`
+	Errors  []ValidateResponse `json:"errors,omitempty"`
+}
+
+var exchangeIntegers = map[string]bool{
+	"id": true, "tinyinteger": true, "smallinteger": true, "integer": true, "biginteger": true, "mediuminteger": true,
+	"unsignedtinyinteger": true, "unsignedsmallinteger": true, "unsignedinteger": true, "unsignedbiginteger": true, "unsignedmediuminteger": true,
+	"increments": true, "tinyincrements": true, "smallincrements": true, "mediumincrements": true, "bigincrements": true,
+}
+
+type exchangeWriter interface {
+	Write(values []interface{}) error
+	Close() error
+}
+
+type exchangeReader interface {
+	Read() ([]interface{}, error)
+}
+
+// ExportTo 导出数据到文件 (CSV, XLSX, NDJSON), 逐页读取并写入, 返回导出记录数
+func (mod *Model) ExportTo(xfs fs.FileSystem, file string, option ExportOption) (int, error) {
+	format, err := exchangeFormat(file, option.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	columns, err := mod.exportColumns(option.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	reader, writer := io.Pipe()
+	go func() {
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = exception.Catch(r)
+			}
+			writer.CloseWithError(err)
+		}()
+		total, err = mod.exportRows(writer, format, columns, option)
+	}()
+
+	_, err = fs.Write(xfs, file, reader, 0644)
+	reader.CloseWithError(err)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// MustExportTo 导出数据到文件, 失败抛出异常
+func (mod *Model) MustExportTo(xfs fs.FileSystem, file string, option ExportOption) int {
+	total, err := mod.ExportTo(xfs, file, option)
+	if err != nil {
+		exception.New("导出失败 %s", 500, err.Error()).Throw()
+	}
+	return total
+}
+
+// ImportFrom 从文件导入数据 (CSV, XLSX, NDJSON), 逐行校验写入, 返回导入报告
+func (mod *Model) ImportFrom(xfs fs.FileSystem, file string, option ImportOption) (*ImportResult, error) {
+	format, err := exchangeFormat(file, option.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	if option.Upsert != "" && !mod.isUnique(option.Upsert) {
+		return nil, fmt.Errorf("the upsert column %s should be unique", option.Upsert)
+	}
+
+	reader, err := fs.Open(xfs, file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	res := &ImportResult{Errors: []ValidateResponse{}}
+	switch format {
+	case FormatNDJSON:
+		err = mod.importNDJSON(reader, option, res)
+	case FormatXLSX:
+		err = mod.importXLSX(reader, option, res)
+	default:
+		buf := bufio.NewReader(reader)
+		if bom, _ := buf.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+			buf.Discard(3)
+		}
+		csvReader := csv.NewReader(buf)
+		csvReader.FieldsPerRecord = -1
+		err = mod.importRows(exchangeCSVReader{csvReader}, option, res)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// MustImportFrom 从文件导入数据, 失败抛出异常
+func (mod *Model) MustImportFrom(xfs fs.FileSystem, file string, option ImportOption) *ImportResult {
+	res, err := mod.ImportFrom(xfs, file, option)
+	if err != nil {
+		exception.New("导入失败 %s", 500, err.Error()).Throw()
+	}
+	return res
+}
+
+// exportColumns the columns to export, default all the columns except the password columns
+func (mod *Model) exportColumns(columns []ExchangeColumn) ([]ExchangeColumn, error) {
+	res := []ExchangeColumn{}
+	if len(columns) == 0 {
+		for _, column := range mod.MetaData.Columns {
+			if strings.ToUpper(column.Crypt) == "PASSWORD" {
+				continue
+			}
+			res = append(res, ExchangeColumn{Name: column.Name, Title: column.Name})
+		}
+		return res, nil
+	}
+
+	for _, column := range columns {
+		if _, has := mod.Columns[column.Name]; !has {
+			return nil, fmt.Errorf("the column %s does not exist", column.Name)
+		}
+		if column.Title == "" {
+			column.Title = column.Name
+		}
+		res = append(res, column)
+	}
+	return res, nil
+}
+
+// exportRows encode the rows to the writer
+func (mod *Model) exportRows(w io.Writer, format string, columns []ExchangeColumn, option ExportOption) (int, error) {
+	var writer exchangeWriter
+	switch format {
+	case FormatNDJSON:
+		writer = &exchangeNDJSONWriter{encoder: json.NewEncoder(w), columns: columns}
+	case FormatXLSX:
+		xlsxWriter, err := xlsx.NewWriter(w)
+		if err != nil {
+			return 0, err
+		}
+		writer = &exchangeXLSXWriter{xlsxWriter}
+	default:
+		writer = &exchangeCSVWriter{csv.NewWriter(w)}
+	}
+
+	if format != FormatNDJSON {
+		header := []interface{}{}
+		for _, column := range columns {
+			header = append(header, column.Title)
+		}
+		if err := writer.Write(header); err != nil {
+			return 0, err
+		}
+	}
+
+	chunkSize := option.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 500
+	}
+
+	param := option.Query
+	param.Select = []interface{}{}
+	for _, column := range columns {
+		param.Select = append(param.Select, column.Name)
+	}
+
+	total := 0
+	err := mod.EachCursor(param, chunkSize, func(rows []maps.MapStr) error {
+		for _, row := range rows {
+			values := []interface{}{}
+			for _, column := range columns {
+				values = append(values, exchangeExportValue(row.Get(column.Name)))
+			}
+			if err := writer.Write(values); err != nil {
+				return err
+			}
+			total++
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return total, writer.Close()
+}
+
+// importNDJSON import the rows of the NDJSON file, each line is an object
+func (mod *Model) importNDJSON(reader io.Reader, option ImportOption, res *ImportResult) error {
+	buf := bufio.NewReader(reader)
+	mapping := mod.importMapping(option.Columns)
+	line := 0
+	for {
+		data, err := buf.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if len(data) == 0 && err == io.EOF {
+			return nil
+		}
+
+		line++
+		text := bytes.TrimSpace(data)
+		if len(text) == 0 {
+			continue
+		}
+
+		res.Total++
+		values := map[string]interface{}{}
+		if err := jsoniter.Unmarshal(text, &values); err != nil {
+			res.fail(line, "", err.Error())
+			continue
+		}
+
+		row := maps.MapStrAny{}
+		for title, value := range values {
+			if column, has := mapping[strings.ToLower(title)]; has {
+				row[column.Name] = exchangeImportValue(column, value)
+			}
+		}
+		mod.importRow(line, row, option, res)
+	}
+}
+
+// importXLSX import the rows of the XLSX file, the file is a zip archive which is read at random positions,
+// the stream is buffered to a temp file if it is not a local file.
+func (mod *Model) importXLSX(reader io.Reader, option ImportOption, res *ImportResult) error {
+	file, ok := reader.(*os.File)
+	if !ok {
+		tmp, err := os.CreateTemp("", "import-*.xlsx")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		_, err = io.Copy(tmp, reader)
+		if err != nil {
+			return err
+		}
+		file = tmp
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	xlsxReader, err := xlsx.NewReader(file, info.Size())
+	if err != nil {
+		return err
+	}
+	defer xlsxReader.Close()
+	return mod.importRows(xlsxReader, option, res)
+}
+
+// importRows import the rows of the CSV or XLSX file, the first line is the header
+func (mod *Model) importRows(reader exchangeReader, option ImportOption, res *ImportResult) error {
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	mapping := mod.importMapping(option.Columns)
+	columns := []*Column{}
+	for _, title := range header {
+		columns = append(columns, mapping[strings.ToLower(strings.TrimSpace(exchangeText(title)))])
+	}
+
+	line := 1
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				res.Total++
+				res.fail(line, "", err.Error())
+				continue
+			}
+			return err
+		}
+
+		if exchangeEmpty(values) {
+			continue
+		}
+
+		res.Total++
+		row := maps.MapStrAny{}
+		for i, value := range values {
+			if i < len(columns) && columns[i] != nil {
+				row[columns[i].Name] = exchangeImportValue(columns[i], value)
+			}
+		}
+		mod.importRow(line, row, option, res)
+	}
+}
+
+// importRow save the row (validated by Create or Update), the errors are added to the report
+func (mod *Model) importRow(line int, row maps.MapStrAny, option ImportOption, res *ImportResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			if errs := exchangeErrors(r); len(errs) > 0 {
+				res.Failed++
+				for _, err := range errs {
+					err.Line = line
+					res.Errors = append(res.Errors, err)
+				}
+				return
+			}
+			res.fail(line, "", exception.Catch(r).Error())
+		}
+	}()
+
+	// the existing row is updated
+	var id interface{}
+	if option.Upsert != "" && row.Get(option.Upsert) != nil {
+		rows, err := mod.Get(QueryParam{
+			Select: []interface{}{mod.PrimaryKey},
+			Wheres: []QueryWhere{{Column: option.Upsert, Value: row.Get(option.Upsert), internal: true}},
+			Limit:  1,
+		})
+		if err != nil {
+			res.fail(line, option.Upsert, err.Error())
+			return
+		}
+
+		if len(rows) > 0 {
+			id = rows[0].Get(mod.PrimaryKey)
+		}
+	}
+
+	if id != nil {
+		if err := mod.Update(id, row); err != nil {
+			res.fail(line, "", err.Error())
+			return
+		}
+		res.Updated++
+		return
+	}
+
+	if _, err := mod.Create(row); err != nil {
+		res.fail(line, "", err.Error())
+		return
+	}
+	res.Created++
+}
+
+// importMapping the lower case title => column mapping, default match the column name, title or label
+func (mod *Model) importMapping(columns []ExchangeColumn) map[string]*Column {
+	mapping := map[string]*Column{}
+	if len(columns) == 0 {
+		for i := range mod.MetaData.Columns {
+			column := mod.Columns[mod.MetaData.Columns[i].Name]
+			for _, title := range []string{column.Title, strings.TrimPrefix(column.Label, "::")} {
+				if title != "" {
+					mapping[strings.ToLower(title)] = column
+				}
+			}
+		}
+		for i := range mod.MetaData.Columns {
+			column := mod.Columns[mod.MetaData.Columns[i].Name]
+			mapping[strings.ToLower(column.Name)] = column
+		}
+		return mapping
+	}
+
+	for _, col := range columns {
+		column, has := mod.Columns[col.Name]
+		if !has {
+			continue
+		}
+		title := col.Title
+		if title == "" {
+			title = col.Name
+		}
+		mapping[strings.ToLower(title)] = column
+	}
+	return mapping
+}
+
+// isUnique check if the column is the primary key or an unique column
+func (mod *Model) isUnique(name string) bool {
+	for _, column := range mod.UniqueColumns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return name == mod.PrimaryKey
+}
+
+func (res *ImportResult) fail(line int, column string, message string) {
+	res.Failed++
+	res.Errors = append(res.Errors, ValidateResponse{Line: line, Column: column, Messages: []string{message}})
+}
+
+// exchangeFormat the format of the file, default the file extension
+func exchangeFormat(file string, format string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(file), ".")
+	}
+
+	switch strings.ToLower(format) {
+	case "csv":
+		return FormatCSV, nil
+	case "xlsx":
+		return FormatXLSX, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("the format %s does not support", format)
+}
+
+// exchangeExportValue the value of the exported cell
+func exchangeExportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return v.Format("2006-01-02 15:04:05")
+	case []byte:
+		return string(v)
+	}
+	return value
+}
+
+// exchangeImportValue cast the value of the cell by the column type, the empty string is nil
+func exchangeImportValue(column *Column, value interface{}) interface{} {
+	typ := strings.ToLower(column.Type)
+	text, ok := value.(string)
+	if !ok {
+		// The numbers of the NDJSON file are float64
+		if v, ok := value.(float64); ok && exchangeIntegers[typ] && v == math.Trunc(v) {
+			return int(v)
+		}
+		return value
+	}
+
+	if text == "" {
+		return nil
+	}
+
+	switch typ {
+	case "float", "double", "decimal", "unsigneddecimal", "unsignedfloat", "unsigneddouble":
+		if v, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			return v
+		}
+
+	case "boolean":
+		if v, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+			return v
+		}
+
+	case "date", "datetime", "datetimetz", "timestamp", "timestamptz":
+		// The date cells of the xlsx file are the serial numbers
+		if serial, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			day := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+			sec := int64(math.Round(serial * 86400))
+			if strings.ToLower(column.Type) == "date" {
+				return day.Add(time.Duration(sec) * time.Second).Format("2006-01-02")
+			}
+			return day.Add(time.Duration(sec) * time.Second).Format("2006-01-02 15:04:05")
+		}
+
+	case "json", "jsonb":
+		var v interface{}
+		if err := jsoniter.UnmarshalFromString(text, &v); err == nil {
+			return v
+		}
+
+	default:
+		if exchangeIntegers[typ] {
+			if v, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err == nil {
+				return int(v)
+			}
+		}
+	}
+
+	return text
+}
+
+// exchangeText the text of the cell
+func exchangeText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case map[string]interface{}, []interface{}, maps.MapStrAny:
+		text, err := jsoniter.MarshalToString(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return text
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// exchangeErrors the validation errors of the exception thrown by Create or Update
+func exchangeErrors(recovered interface{}) []ValidateResponse {
+	switch err := recovered.(type) {
+	case exception.Exception:
+		errs, _ := err.Context.([]ValidateResponse)
+		return errs
+	case *exception.Exception:
+		errs, _ := err.Context.([]ValidateResponse)
+		return errs
+	}
+	return nil
+}
+
+func exchangeEmpty(values []interface{}) bool {
+	for _, value := range values {
+		if exchangeText(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type exchangeCSVWriter struct{ writer *csv.Writer }
+
+func (w *exchangeCSVWriter) Write(values []interface{}) error {
+	record := []string{}
+	for _, value := range values {
+		record = append(record, exchangeText(value))
+	}
+	return w.writer.Write(record)
+}
+
+func (w *exchangeCSVWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type exchangeCSVReader struct{ reader *csv.Reader }
+
+func (r exchangeCSVReader) Read() ([]interface{}, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{}
+	for _, value := range record {
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+type exchangeNDJSONWriter struct {
+	encoder *json.Encoder
+	columns []ExchangeColumn
+}
+
+func (w *exchangeNDJSONWriter) Write(values []interface{}) error {
+	row := map[string]interface{}{}
+	for i, column := range w.columns {
+		row[column.Title] = values[i]
+	}
+	return w.encoder.Encode(row)
+}
+
+func (w *exchangeNDJSONWriter) Close() error { return nil }
+
+type exchangeXLSXWriter struct{ writer *xlsx.Writer }
+
+// Write write the numbers as they are, the other values are written as the text
+func (w *exchangeXLSXWriter) Write(values []interface{}) error {
+	cells := []interface{}{}
+	for _, value := range values {
+		switch value.(type) {
+		case nil, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			cells = append(cells, value)
+		default:
+			cells = append(cells, exchangeText(value))
+		}
+	}
+	return w.writer.Write(cells)
+}
+
+func (w *exchangeXLSXWriter) Close() error { return w.writer.Close() }
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/fs"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/any"
+)
+
+func TestExchangeExportImport(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.exchange", 5)
+	xfs := fs.MustGet("system")
+	dir := t.TempDir()
+
+	for _, format := range []string{"csv", "xlsx", "ndjson"} {
+		file := filepath.Join(dir, "users."+format)
+		total, err := mod.ExportTo(xfs, file, ExportOption{
+			Columns:   []ExchangeColumn{{Name: "email", Title: "Email"}, {Name: "name", Title: "Name"}, {Name: "score"}},
+			Query:     QueryParam{Orders: []QueryOrder{{Column: "score"}}},
+			ChunkSize: 2,
+		})
+		assert.Nil(t, err, format)
+		assert.Equal(t, 5, total, format)
+
+		// import into the empty table
+		mod.MustDestroyWhere(QueryParam{})
+		res, err := mod.ImportFrom(xfs, file, ImportOption{
+			Columns: []ExchangeColumn{{Name: "email", Title: "Email"}, {Name: "name", Title: "Name"}, {Name: "score"}},
+		})
+		assert.Nil(t, err, format)
+		assert.Equal(t, 5, res.Total, format)
+		assert.Equal(t, 5, res.Created, format)
+		assert.Equal(t, 0, res.Failed, format)
+
+		rows := mod.MustGet(QueryParam{Orders: []QueryOrder{{Column: "score"}}})
+		assert.Len(t, rows, 5, format)
+		assert.Equal(t, "user-0@test.com", rows[0].Get("email"), format)
+		assert.Equal(t, 4, any.Of(rows[4].Get("score")).CInt(), format)
+	}
+}
+
+func TestExchangeImportErrors(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.exchange", 5)
+	xfs := fs.MustGet("system")
+	file := filepath.Join(t.TempDir(), "users.csv")
+
+	_, err := xfs.WriteFile(file, []byte(strings.Join([]string{
+		"email,name,score",
+		"user-1@test.com,Updated,10",
+		"new@test.com,New,",
+		"bad-email,Bad,3",
+		"",
+		"other@test.com,Other,abc",
+	}, "\n")), 0644)
+	assert.Nil(t, err)
+
+	res, err := mod.ImportFrom(xfs, file, ImportOption{Upsert: "email"})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, res.Total)
+	assert.Equal(t, 1, res.Created)
+	assert.Equal(t, 1, res.Updated)
+	assert.Equal(t, 2, res.Failed)
+	assert.Equal(t, 4, res.Errors[0].Line)
+	assert.Equal(t, "email", res.Errors[0].Column)
+	assert.Equal(t, 6, res.Errors[1].Line)
+	assert.Equal(t, "score", res.Errors[1].Column)
+
+	row := mod.MustFind(2, QueryParam{})
+	assert.Equal(t, "Updated", row.Get("name"))
+	assert.Equal(t, 10, any.Of(row.Get("score")).CInt())
+	assert.Len(t, mod.MustGet(QueryParam{}), 6)
+
+	// The upsert column should be unique
+	_, err = mod.ImportFrom(xfs, file, ImportOption{Upsert: "name"})
+	assert.NotNil(t, err)
+
+	// The format does not support
+	_, err = mod.ImportFrom(xfs, filepath.Join(t.TempDir(), "users.txt"), ImportOption{})
+	assert.NotNil(t, err)
+}
+
+func TestExchangeProcess(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	seedTests(t, "tests.exchange", 5)
+	file := filepath.Join(t.TempDir(), "users.xlsx")
+
+	total := process.New("models.tests.exchange.ExportTo", file, map[string]interface{}{
+		"query": map[string]interface{}{"wheres": []map[string]interface{}{{"column": "score", "op": "ge", "value": 3}}},
+	}).Run()
+	assert.Equal(t, 2, total)
+
+	res := process.New("models.tests.exchange.ImportFrom", file, map[string]interface{}{"upsert": "email"}).Run().(*ImportResult)
+	assert.Equal(t, 2, res.Total)
+	assert.Equal(t, 2, res.Updated)
+	assert.Equal(t, 0, res.Created)
+}
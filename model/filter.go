@@ -8,17 +8,21 @@ import (
 
 // FliterIn 输入前过滤解码
 func (mod *Model) FliterIn(row maps.MapStrAny) {
+	acl := mod.acl()
 	for name, value := range row {
 		column, has := mod.Columns[name]
 
-		// 删除无效字段
-		if !has {
+		// 删除无效字段 (虚拟字段不可写入)
+		if !has || column.Virtual != nil {
 			row.Del(name)
 			continue
 		}
 
+		// 字段写权限
+		mod.checkWritable(acl, name)
+
 		// 过滤输入信息
-		column.FliterIn(value, row)
+		column.fliterIn(mod, value, row)
 	}
 }
 
@@ -29,6 +33,7 @@ func (mod *Model) Filterselect(alias string, columns []interface{}, cmap map[str
 		cmap = map[string]ColumnMap{}
 	}
 
+	acl := mod.acl()
 	for _, col := range columns {
 
 		if _, ok := col.(dbal.Expression); ok {
@@ -42,7 +47,7 @@ func (mod *Model) Filterselect(alias string, columns []interface{}, cmap map[str
 		}
 
 		column, has := mod.Columns[name]
-		if !has {
+		if !has || !acl.readable(name) { // 无读权限的字段
 			continue
 		}
 
@@ -65,8 +70,14 @@ func (mod *Model) Filterselect(alias string, columns []interface{}, cmap map[str
 			Export: export,
 		}
 
+		// 虚拟字段
+		if column.Virtual != nil {
+			res = append(res, column.virtualSelect(mod, alias, varName))
+			continue
+		}
+
 		// 加密字段
-		if column.Crypt == "AES" && column.model.Driver == "mysql" {
+		if column.Crypt == "AES" && mod.Driver == "mysql" {
 			icrypt, err := SelectCrypt(column.Crypt)
 			if err != nil {
 				exception.New(err.Error(), 400).Throw()
@@ -82,6 +93,11 @@ func (mod *Model) Filterselect(alias string, columns []interface{}, cmap map[str
 			res = append(res, raw)
 		}
 	}
+
+	// 字段均无读权限时仅选择主键, 避免 select *
+	if len(res) == 0 && len(columns) > 0 && acl != nil {
+		return mod.Filterselect(alias, []interface{}{mod.PrimaryKey}, cmap, exportPrefix)
+	}
 	return res
 }
 
@@ -101,13 +117,18 @@ func (mod *Model) FliterWhere(alias string, col interface{}) interface{} {
 		return col
 	}
 
+	// 虚拟字段
+	if column.Virtual != nil {
+		return dbal.Raw(column.virtualExpression(mod, alias))
+	}
+
 	// alias.field
 	if alias != "" {
 		name = alias + "." + name
 	}
 
 	// 加密字段
-	if column.Crypt == "AES" && column.model.Driver == "mysql" {
+	if column.Crypt == "AES" && mod.Driver == "mysql" {
 		icrypt, err := SelectCrypt(column.Crypt)
 		if err != nil {
 			exception.New(err.Error(), 400).Throw()
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/log"
+	"github.com/yaoapp/kun/maps"
+)
+
+// before run the before hook, the row is replaced with the result of the process if it returns a map.
+// The exception thrown by the process aborts the operation.
+func (mod *Model) before(name string, row maps.MapStrAny, args ...interface{}) {
+	if name == "" {
+		return
+	}
+
+	res := mod.hook(process.New(name, append([]interface{}{row}, args...)...)).Run()
+	mod.hookReplace(row, res)
+}
+
+// beforeDelete run the beforeDelete hook with the id of the row, the hook could abort the deletion by throwing an exception
+func (mod *Model) beforeDelete(id interface{}) {
+	if mod.MetaData.Hooks.BeforeDelete == "" {
+		return
+	}
+	mod.hook(process.New(mod.MetaData.Hooks.BeforeDelete, id)).Run()
+}
+
+// after run the after hook, the errors are logged only because the data has been written
+func (mod *Model) after(name string, args ...interface{}) {
+	if name == "" {
+		return
+	}
+
+	p, err := process.Of(name, args...)
+	if err == nil {
+		_, err = mod.hook(p).Exec()
+	}
+
+	if err != nil {
+		log.Error("[Model] %s hook %s: %s", mod.ID, name, err.Error())
+	}
+}
+
+// afterFind run the afterFind hook, the row is replaced with the result of the process if it returns a map
+func (mod *Model) afterFind(row maps.MapStr) maps.MapStr {
+	if mod.MetaData.Hooks.AfterFind == "" {
+		return row
+	}
+
+	res := mod.hook(process.New(mod.MetaData.Hooks.AfterFind, row)).Run()
+	mod.hookReplace(row, res)
+	return row
+}
+
+// hook bind the session and the global vars of the caller to the hook process, the hook runs in the transaction of the caller
+func (mod *Model) hook(p *process.Process) *process.Process {
+	p.WithSID(mod.sid)
+	if mod.global != nil {
+		p.WithGlobal(mod.global)
+		if root, _ := mod.global[process.RootKey].(bool); root {
+			p.WithRoot(true)
+		}
+	}
+	return p
+}
+
+// hookReplace replace the content of the row with the result of the hook
+func (mod *Model) hookReplace(row maps.MapStrAny, res interface{}) {
+	var values map[string]interface{}
+	switch value := res.(type) {
+	case map[string]interface{}:
+		values = value
+	case maps.MapStrAny:
+		values = value
+	default:
+		return
+	}
+
+	// the result could be the row itself (mutated by the go handlers)
+	copied := map[string]interface{}{}
+	for key, value := range values {
+		copied[key] = value
+	}
+
+	for key := range row {
+		delete(row, key)
+	}
+
+	for key, value := range copied {
+		row[key] = value
+	}
+}
+
+// hookRow copy the row for the after hooks, the row is changed by the filters before writing
+func hookRow(row maps.MapStrAny) maps.MapStrAny {
+	copied := maps.MapStrAny{}
+	for key, value := range row {
+		copied[key] = value
+	}
+	return copied
+}
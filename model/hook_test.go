@@ -0,0 +1,92 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestHooks(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+
+	calls := []string{}
+	process.Register("tests.hooks.beforecreate", func(p *process.Process) interface{} {
+		calls = append(calls, "beforeCreate")
+		row := p.ArgsMap(0)
+		row["name"] = strings.TrimSpace(any.Of(row["name"]).CString())
+		return row
+	})
+	process.Register("tests.hooks.aftercreate", func(p *process.Process) interface{} {
+		calls = append(calls, "afterCreate")
+		return nil
+	})
+	process.Register("tests.hooks.beforesave", func(p *process.Process) interface{} {
+		calls = append(calls, "beforeSave")
+		row := p.ArgsMap(0)
+		if row["name"] == "forbidden" {
+			exception.New("the name is forbidden", 400).Throw()
+		}
+		row["name"] = strings.ToUpper(any.Of(row["name"]).CString())
+		return row
+	})
+	process.Register("tests.hooks.aftersave", func(p *process.Process) interface{} {
+		calls = append(calls, "afterSave")
+		return nil
+	})
+	process.Register("tests.hooks.beforedelete", func(p *process.Process) interface{} {
+		calls = append(calls, "beforeDelete")
+		return nil
+	})
+	process.Register("tests.hooks.afterdelete", func(p *process.Process) interface{} {
+		calls = append(calls, "afterDelete")
+		return nil
+	})
+	process.Register("tests.hooks.afterfind", func(p *process.Process) interface{} {
+		row := p.ArgsMap(0)
+		row["label"] = "#" + any.Of(row["name"]).CString()
+		return row
+	})
+
+	mod := prepareTests(t, "tests.hooks")
+
+	id := mod.MustCreate(maps.MapStrAny{"name": "  foo  "})
+	assert.Equal(t, []string{"beforeCreate", "afterCreate"}, calls)
+
+	row := mod.MustFind(id, QueryParam{})
+	assert.Equal(t, "foo", row.Get("name"))
+	assert.Equal(t, "#foo", row.Get("label"))
+
+	calls = []string{}
+	mod.MustSave(maps.MapStrAny{"id": id, "name": "bar"})
+	assert.Equal(t, []string{"beforeSave", "afterSave"}, calls)
+	rows := mod.MustGet(QueryParam{})
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "BAR", rows[0].Get("name"))
+	assert.Equal(t, "#BAR", rows[0].Get("label"))
+
+	calls = []string{}
+	mod.MustSave(maps.MapStrAny{"name": " baz "})
+	assert.Equal(t, []string{"beforeSave", "beforeCreate", "afterCreate", "afterSave"}, calls)
+
+	// Abort
+	assert.Panics(t, func() { mod.MustUpdate(id, maps.MapStrAny{"name": "forbidden"}) })
+	assert.Equal(t, "BAR", mod.MustFind(id, QueryParam{}).Get("name"))
+
+	calls = []string{}
+	mod.MustDelete(id)
+	assert.Equal(t, []string{"beforeDelete", "afterDelete"}, calls)
+
+	// the afterDelete hook is not run if no rows are deleted
+	calls = []string{}
+	err := mod.Delete(id)
+	assert.True(t, errors.Is(err, errDeleteNotFound))
+	assert.Equal(t, []string{"beforeDelete"}, calls)
+	assert.Equal(t, 404, exceptionCode(func() { mod.MustDelete(id) }))
+}
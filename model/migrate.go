@@ -14,7 +14,6 @@ import (
 	"github.com/yaoapp/gou/schema/types"
 	"github.com/yaoapp/kun/any"
 	"github.com/yaoapp/kun/day"
-	"github.com/yaoapp/xun/capsule"
 )
 
 // CreateTable create the table of the model
@@ -108,8 +107,9 @@ func (mod *Model) InsertValues() ([]int, []error) {
 	errs := []error{}
 
 	// Add the default values
+	root := mod.WithRoot()
 	for _, row := range mod.MetaData.Values {
-		id, err := mod.Create(row)
+		id, err := root.Create(row)
 		if err != nil {
 			errs = append(errs, nil)
 		}
@@ -121,7 +121,14 @@ func (mod *Model) InsertValues() ([]int, []error) {
 
 // Blueprint cast to the blueprint struct
 func (mod *Model) Blueprint() (types.Blueprint, error) {
-	return types.NewAny(mod.MetaData)
+	metadata := mod.MetaData
+	metadata.Columns = []Column{}
+	for _, column := range mod.MetaData.Columns {
+		if column.Virtual == nil { // 虚拟字段不创建数据表字段
+			metadata.Columns = append(metadata.Columns, column)
+		}
+	}
+	return types.NewAny(metadata)
 }
 
 // Export the model
@@ -130,7 +137,7 @@ func (mod *Model) Export(chunkSize int, process func(curr, total int)) ([]string
 	tmpdir := filepath.Join(os.TempDir(), fmt.Sprintf("%s-%s", mod.Name, time.Now().Format("20060102150405")))
 	os.MkdirAll(tmpdir, 0755)
 
-	qb := capsule.Query().Table(mod.MetaData.Table.Name).OrderBy(mod.PrimaryKey)
+	qb := mod.newQuery().Table(mod.MetaData.Table.Name).OrderBy(mod.PrimaryKey)
 	total, err := qb.Count()
 	if err != nil {
 		return nil, err
@@ -237,6 +244,6 @@ func (mod *Model) Import(file string) error {
 		return err
 	}
 
-	qb := capsule.Query().Table(mod.MetaData.Table.Name)
+	qb := mod.newQuery().Table(mod.MetaData.Table.Name)
 	return qb.Insert(data.Values, data.Columns)
 }
@@ -3,26 +3,44 @@ package model
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/yaoapp/gou/application"
 	"github.com/yaoapp/kun/exception"
 	"github.com/yaoapp/kun/log"
 	"github.com/yaoapp/kun/maps"
-	"github.com/yaoapp/xun/capsule"
 )
 
 // Models 已载入模型
 var Models = map[string]*Model{}
 
+// modelsMutex guard the loaded models, the models could be reloaded while the requests are served
+var modelsMutex sync.RWMutex
+
+// Loaded return a copy of the loaded models, it is safe to iterate while the models are loading or reloading
+func Loaded() map[string]*Model {
+	modelsMutex.RLock()
+	defer modelsMutex.RUnlock()
+	models := make(map[string]*Model, len(Models))
+	for id, mod := range Models {
+		models[id] = mod
+	}
+	return models
+}
+
 // Load 载入数据模型
 func Load(file string, id string) (*Model, error) {
 	data, err := application.App.Read(file)
 	if err != nil {
 		return nil, err
 	}
+	return LoadSource(data, file, id)
+}
 
+// LoadSource load the model from the source
+func LoadSource(data []byte, file string, id string) (*Model, error) {
 	metadata := MetaData{}
-	err = application.Parse(file, data, &metadata)
+	err := application.Parse(file, data, &metadata)
 	if err != nil {
 		exception.Err(err, 400).Throw()
 	}
@@ -71,6 +89,56 @@ func Load(file string, id string) (*Model, error) {
 		)
 	}
 
+	// 补充操作人(Trackings)
+	if mod.MetaData.Option.Trackings {
+		mod.MetaData.Columns = append(mod.MetaData.Columns,
+			Column{
+				Label:    "::Created By",
+				Name:     "created_by",
+				Type:     "bigInteger",
+				Comment:  "::Created By",
+				Nullable: true,
+			},
+			Column{
+				Label:    "::Updated By",
+				Name:     "updated_by",
+				Type:     "bigInteger",
+				Comment:  "::Updated By",
+				Nullable: true,
+			},
+			Column{
+				Label:    "::Deleted By",
+				Name:     "deleted_by",
+				Type:     "bigInteger",
+				Comment:  "::Deleted By",
+				Nullable: true,
+			},
+		)
+	}
+
+	// 补充版本号(乐观锁)
+	if mod.MetaData.Option.Version {
+		mod.MetaData.Columns = append(mod.MetaData.Columns, Column{
+			Label:   "::Version",
+			Name:    VersionColumn,
+			Type:    "bigInteger",
+			Comment: "::Version",
+			Default: 1,
+		})
+	}
+
+	// 补充租户字段
+	if column := mod.tenantColumn(); column != "" && !mod.hasMetaColumn(column) {
+		mod.MetaData.Columns = append(mod.MetaData.Columns, Column{
+			Label:    "::Tenant",
+			Name:     column,
+			Type:     "bigInteger",
+			Comment:  "::Tenant",
+			Nullable: true,
+			Index:    true,
+		})
+	}
+
 	for i, column := range mod.MetaData.Columns {
 		mod.MetaData.Columns[i].model = mod // 链接所属模型
 		columns[column.Name] = &mod.MetaData.Columns[i]
@@ -110,11 +178,19 @@ func Load(file string, id string) (*Model, error) {
 	mod.PrimaryKey = PrimaryKey
 	mod.UniqueColumns = uniqueColumns
 
-	if capsule.Global != nil {
-		mod.Driver = capsule.Schema().MustGetConnection().Config.Driver
+	if manager, err := connectorManager(mod.MetaData.Connector); err == nil {
+		mod.Driver = manager.Schema().MustGetConnection().Config.Driver
+	} else if mod.MetaData.Connector != "" {
+		log.Warn("[Model] %s: %s", id, err.Error())
 	}
 
+	if err := mod.validateVirtual(); err != nil {
+		return nil, err
+	}
+
+	modelsMutex.Lock()
 	Models[id] = mod
+	modelsMutex.Unlock()
 	return mod, nil
 }
 
@@ -163,7 +239,9 @@ func (mod *Model) Migrate(force bool) error {
 
 // Select 读取已加载模型
 func Select(id string) *Model {
+	modelsMutex.RLock()
 	mod, has := Models[id]
+	modelsMutex.RUnlock()
 	if !has {
 		exception.New(
 			fmt.Sprintf("Model:%s; 尚未加载", id),
@@ -173,27 +251,11 @@ func Select(id string) *Model {
 	return mod
 }
 
-// Validate 数值校验
-func (mod *Model) Validate(row maps.MapStrAny) []ValidateResponse {
-	res := []ValidateResponse{}
-	for name, value := range row {
-		column, has := mod.Columns[name]
-		if !has {
-			continue
-		}
-
-		// 如果允许为 null
-		if value == nil && column.Nullable {
-			continue
-		}
-
-		success, messages := column.Validate(value, row)
-		if !success {
-			res = append(res, ValidateResponse{
-				Column:   column.Name,
-				Messages: messages,
-			})
-		}
+// Validate 数值校验, id 为更新数据的主键 (默认为 row 中的主键, unique 校验时排除该记录)
+func (mod *Model) Validate(row maps.MapStrAny, id ...interface{}) []ValidateResponse {
+	ctx := validationContext{mod: mod, row: row, id: row.Get(mod.PrimaryKey)}
+	if len(id) > 0 {
+		ctx.id = id[0]
 	}
-	return res
+	return mod.validate(ctx)
 }
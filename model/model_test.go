@@ -1,8 +1,10 @@
 package model
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,6 +12,7 @@ import (
 	"github.com/yaoapp/gou/query"
 	"github.com/yaoapp/gou/query/gou"
 	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
 	"github.com/yaoapp/xun/capsule"
 )
 
@@ -78,6 +81,70 @@ func prepare(t *testing.T) {
 
 }
 
+// prepareTests load and migrate the models of the testdata dir, eg: tests.rel.user => testdata/tests/rel/user.mod.json.
+// return the first model
+func prepareTests(t *testing.T, ids ...string) *Model {
+	mods := []*Model{}
+	for _, id := range ids {
+		file := filepath.Join("testdata", filepath.Join(strings.Split(id, ".")...)+".mod.json")
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mod, err := LoadSource(source, file, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mods = append(mods, mod)
+	}
+
+	for _, mod := range mods {
+		err := mod.Migrate(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return mods[0]
+}
+
+// testSeeds the seed rows of the test models, the function returns the i-th row
+var testSeeds = map[string]func(i int) maps.MapStrAny{
+	"tests.cursor": func(i int) maps.MapStrAny {
+		return maps.MapStrAny{"name": fmt.Sprintf("user-%d", i), "score": i}
+	},
+	"tests.exchange": func(i int) maps.MapStrAny {
+		return maps.MapStrAny{"email": fmt.Sprintf("user-%d@test.com", i), "name": fmt.Sprintf("user-%d", i), "score": i}
+	},
+	"tests.permission": func(i int) maps.MapStrAny {
+		return maps.MapStrAny{"name": "foo", "salary": 5000, "password": "secret"}
+	},
+	"tests.restore": func(i int) maps.MapStrAny {
+		return maps.MapStrAny{"email": fmt.Sprintf("user-%d@test.com", i), "score": i}
+	},
+	"tests.upsert": func(i int) maps.MapStrAny {
+		return maps.MapStrAny{"email": fmt.Sprintf("user-%d@test.com", i), "name": fmt.Sprintf("user-%d", i)}
+	},
+	"tests.virtual": func(i int) maps.MapStrAny {
+		return maps.MapStrAny{"name": fmt.Sprintf("user-%d", i), "score": i}
+	},
+}
+
+// seedTests load and migrate the model of the testdata dir (see prepareTests) and create n seed rows (see testSeeds).
+// the rows are created by the root, the permissions of the model are skipped
+func seedTests(t *testing.T, id string, n int) *Model {
+	mod := prepareTests(t, id)
+	seed, has := testSeeds[id]
+	if !has {
+		t.Fatalf("the seed rows of %s are not defined", id)
+	}
+
+	for i := 0; i < n; i++ {
+		mod.WithRoot().MustCreate(seed(i))
+	}
+	return mod
+}
+
 func check(t *testing.T) {
 	keys := map[string]bool{}
 	for id := range Models {
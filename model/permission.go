@@ -0,0 +1,108 @@
+package model
+
+import (
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/gou/session"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/log"
+)
+
+// PermissionSessionKey the session key of the roles of the caller
+var PermissionSessionKey = "role"
+
+// PermissionGlobalKey the global var name of the roles of the caller, it is used before the session
+var PermissionGlobalKey = "__role"
+
+// columnACL the readable and writable columns of the caller
+type columnACL struct {
+	read  map[string]bool
+	write map[string]bool
+	keys  map[string]bool
+}
+
+// acl the column permissions of the caller, return nil if the model does not enable the permission option or the caller has the root privileges
+func (mod *Model) acl() *columnACL {
+	if !mod.MetaData.Option.Permission {
+		return nil
+	}
+
+	if root, _ := mod.global[process.RootKey].(bool); root {
+		return nil
+	}
+
+	acl := &columnACL{read: map[string]bool{}, write: map[string]bool{}, keys: map[string]bool{mod.PrimaryKey: true}}
+	for _, role := range append(mod.roles(), "*") {
+		perm, has := mod.MetaData.Permissions[role]
+		if !has {
+			continue
+		}
+		for _, name := range perm.Read {
+			acl.read[name] = true
+		}
+		for _, name := range perm.Write {
+			acl.write[name] = true
+		}
+	}
+
+	// the relation keys are always readable
+	for _, rel := range mod.MetaData.Relations {
+		acl.keys[rel.Key] = true
+		acl.keys[rel.Foreign] = true
+	}
+	return acl
+}
+
+// roles resolve the roles of the caller (the global var first, then the session)
+func (mod *Model) roles() []string {
+	value, has := mod.global[PermissionGlobalKey]
+	if (!has || value == nil) && mod.sid != "" {
+		var err error
+		value, err = session.Global().ID(mod.sid).Get(PermissionSessionKey)
+		if err != nil {
+			log.Warn("[Model] %s session role: %s", mod.ID, err.Error())
+		}
+	}
+
+	switch roles := value.(type) {
+	case string:
+		return []string{roles}
+	case []string:
+		return roles
+	case []interface{}:
+		res := []string{}
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				res = append(res, name)
+			}
+		}
+		return res
+	}
+	return []string{}
+}
+
+// readable check if the column can be read, the primary key and the relation keys are always readable
+func (acl *columnACL) readable(name string) bool {
+	return acl == nil || acl.keys[name] || acl.read["*"] || acl.read[name]
+}
+
+// writable check if the column can be written
+func (acl *columnACL) writable(name string) bool {
+	return acl == nil || acl.write["*"] || acl.write[name]
+}
+
+// checkReadable throw a 403 exception if the column can not be read by the caller, the columns of the query conditions and the orders should be readable
+func (mod *Model) checkReadable(acl *columnACL, name string) {
+	if acl.readable(name) {
+		return
+	}
+	exception.New("%s: the column %s is not readable", 403, mod.ID, name).Throw()
+}
+
+// checkWritable throw a 403 exception if the column can not be written by the caller,
+// the primary key, the version column and the tenant column are managed by the model.
+func (mod *Model) checkWritable(acl *columnACL, name string) {
+	if acl.writable(name) || name == mod.PrimaryKey || name == VersionColumn || name == mod.tenantColumn() {
+		return
+	}
+	exception.New("%s: the column %s is not writable", 403, mod.ID, name).Throw()
+}
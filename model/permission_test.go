@@ -0,0 +1,109 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestPermissionColumns(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.permission", 1)
+	guest := mod.WithGlobal(map[string]interface{}{})
+	hr := mod.WithGlobal(map[string]interface{}{PermissionGlobalKey: "hr"})
+
+	// the unreadable columns are stripped
+	row := guest.MustFind(1, QueryParam{})
+	assert.Equal(t, "foo", row.Get("name"))
+	assert.False(t, row.Has("salary"))
+	assert.False(t, row.Has("password"))
+
+	row = guest.MustFind(1, QueryParam{Select: []interface{}{"salary"}})
+	assert.Equal(t, 1, any.Of(row.Get("id")).CInt())
+	assert.False(t, row.Has("salary"))
+
+	row = hr.MustFind(1, QueryParam{})
+	assert.Equal(t, 5000, any.Of(row.Get("salary")).CInt())
+	assert.False(t, row.Has("password"))
+
+	assert.Panics(t, func() {
+		guest.MustGet(QueryParam{Aggregates: []QueryAggregate{{Func: "sum", Column: "salary"}}})
+	})
+
+	// the unwritable columns are rejected
+	guest.MustUpdate(1, maps.MapStrAny{"name": "bar"})
+	assert.Panics(t, func() { guest.MustUpdate(1, maps.MapStrAny{"salary": 9000}) })
+	assert.Panics(t, func() { hr.MustUpdate(1, maps.MapStrAny{"password": "changed"}) })
+	hr.MustUpdate(1, maps.MapStrAny{"salary": 6000})
+
+	// root privileges
+	row = mod.WithRoot().MustFind(1, QueryParam{})
+	assert.Equal(t, "bar", row.Get("name"))
+	assert.Equal(t, 6000, any.Of(row.Get("salary")).CInt())
+	assert.True(t, row.Has("password"))
+}
+
+func TestPermissionWheres(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.permission", 1)
+	guest := mod.WithGlobal(map[string]interface{}{})
+	hr := mod.WithGlobal(map[string]interface{}{PermissionGlobalKey: "hr"})
+
+	// the unreadable columns can not be filtered, ordered or grouped
+	assert.Equal(t, 403, exceptionCode(func() {
+		guest.MustGet(QueryParam{Wheres: []QueryWhere{{Column: "salary", OP: "ge", Value: 5000}}})
+	}))
+	assert.Equal(t, 403, exceptionCode(func() {
+		guest.MustGet(QueryParam{Wheres: []QueryWhere{{Wheres: []QueryWhere{{Column: "password", Value: "secret"}}}}})
+	}))
+	assert.Equal(t, 403, exceptionCode(func() {
+		guest.MustGet(QueryParam{Orders: []QueryOrder{{Column: "salary"}}})
+	}))
+	assert.Equal(t, 403, exceptionCode(func() {
+		guest.MustCursor(QueryParam{Orders: []QueryOrder{{Column: "salary"}}}, "", 10)
+	}))
+	assert.Equal(t, 403, exceptionCode(func() {
+		guest.MustGet(QueryParam{Groups: []string{"salary"}, Aggregates: []QueryAggregate{{Func: "count"}}})
+	}))
+	assert.Equal(t, 403, exceptionCode(func() {
+		guest.MustDeleteWhere(QueryParam{Wheres: []QueryWhere{{Column: "salary", Value: 5000}}})
+	}))
+
+	// the readable columns and the primary key
+	rows := guest.MustGet(QueryParam{
+		Wheres: []QueryWhere{{Column: "name", Value: "foo"}, {Column: "id", Value: 1}},
+		Orders: []QueryOrder{{Column: "name"}},
+	})
+	assert.Len(t, rows, 1)
+
+	rows = hr.MustGet(QueryParam{
+		Wheres: []QueryWhere{{Column: "salary", OP: "ge", Value: 5000}},
+		Orders: []QueryOrder{{Column: "salary", Option: "desc"}},
+	})
+	assert.Len(t, rows, 1)
+
+	// the internal conditions are not checked
+	rows = guest.MustGet(QueryParam{Wheres: []QueryWhere{{Column: "salary", Value: 5000, internal: true}}})
+	assert.Len(t, rows, 1)
+}
+
+func TestPermissionProcess(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	seedTests(t, "tests.permission", 1)
+
+	row := process.New("models.tests.permission.Find", 1, map[string]interface{}{}).
+		WithGlobal(map[string]interface{}{PermissionGlobalKey: []interface{}{"hr"}}).
+		Run().(maps.MapStr)
+	assert.Equal(t, 5000, any.Of(row.Get("salary")).CInt())
+
+	_, err := process.New("models.tests.permission.Save", map[string]interface{}{"id": 1, "salary": 0}).
+		WithGlobal(map[string]interface{}{}).
+		Exec()
+	assert.NotNil(t, err)
+}
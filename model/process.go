@@ -1,8 +1,11 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 
+	jsoniter "github.com/json-iterator/go"
+	"github.com/yaoapp/gou/fs"
 	"github.com/yaoapp/gou/process"
 	"github.com/yaoapp/kun/any"
 	"github.com/yaoapp/kun/exception"
@@ -22,11 +25,20 @@ var ModelHandlers = map[string]process.Handler{
 	"delete":              processDelete,
 	"destroy":             processDestroy,
 	"insert":              processInsert,
+	"upsert":              processUpsert,
 	"updatewhere":         processUpdateWhere,
 	"deletewhere":         processDeleteWhere,
 	"destroywhere":        processDestroyWhere,
+	"restore":             processRestore,
+	"restorewhere":        processRestoreWhere,
 	"eachsave":            processEachSave,
 	"eachsaveafterdelete": processEachSaveAfterDelete,
+	"history":             processHistory,
+	"transaction":         processTransaction,
+	"aggregate":           processAggregate,
+	"cursor":              processCursor,
+	"exportto":            processExportTo,
+	"importfrom":          processImportFrom,
 }
 
 func init() {
@@ -36,7 +48,7 @@ func init() {
 // processFind 运行模型 MustFind
 func processFind(process *process.Process) interface{} {
 	process.ValidateArgNums(2)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	params, ok := AnyToQueryParam(process.Args[1])
 	if !ok {
 		params = QueryParam{}
@@ -47,7 +59,7 @@ func processFind(process *process.Process) interface{} {
 // processGet 运行模型 MustGet
 func processGet(process *process.Process) interface{} {
 	process.ValidateArgNums(1)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	params, ok := AnyToQueryParam(process.Args[0])
 	if !ok {
 		exception.New("第1个查询参数错误 %v", 400, process.Args[0]).Throw()
@@ -55,10 +67,83 @@ func processGet(process *process.Process) interface{} {
 	return mod.MustGet(params)
 }
 
+// processAggregate 运行模型 MustAggregate
+func processAggregate(process *process.Process) interface{} {
+	process.ValidateArgNums(1)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	params, ok := AnyToQueryParam(process.Args[0])
+	if !ok {
+		exception.New("第1个查询参数错误 %v", 400, process.Args[0]).Throw()
+	}
+	return mod.MustAggregate(params)
+}
+
+// processCursor 运行模型 MustCursor (param, after, limit)
+func processCursor(process *process.Process) interface{} {
+	process.ValidateArgNums(1)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	params, ok := AnyToQueryParam(process.Args[0])
+	if !ok {
+		exception.New("第1个查询参数错误 %v", 400, process.Args[0]).Throw()
+	}
+
+	after := ""
+	if process.NumOfArgs() > 1 && process.Args[1] != nil {
+		after = process.ArgsString(1)
+	}
+
+	limit := 20
+	if process.NumOfArgs() > 2 {
+		limit = process.ArgsInt(2, 20)
+	}
+	return mod.MustCursor(params, after, limit)
+}
+
+// processExportTo 运行模型 MustExportTo (file, option), option.fs 文件系统名称 (默认 system)
+func processExportTo(process *process.Process) interface{} {
+	process.ValidateArgNums(1)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	option := ExportOption{}
+	if process.NumOfArgs() > 1 && process.Args[1] != nil {
+		bytes, err := jsoniter.Marshal(process.Args[1])
+		if err == nil {
+			err = jsoniter.Unmarshal(bytes, &option)
+		}
+		if err != nil {
+			exception.New("第2个导出参数错误 %v", 400, process.Args[1]).Throw()
+		}
+	}
+	return mod.MustExportTo(exchangeFS(option.FS), process.ArgsString(0), option)
+}
+
+// processImportFrom 运行模型 MustImportFrom (file, option), option.fs 文件系统名称 (默认 system)
+func processImportFrom(process *process.Process) interface{} {
+	process.ValidateArgNums(1)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	option := ImportOption{}
+	if process.NumOfArgs() > 1 && process.Args[1] != nil {
+		bytes, err := jsoniter.Marshal(process.Args[1])
+		if err == nil {
+			err = jsoniter.Unmarshal(bytes, &option)
+		}
+		if err != nil {
+			exception.New("第2个导入参数错误 %v", 400, process.Args[1]).Throw()
+		}
+	}
+	return mod.MustImportFrom(exchangeFS(option.FS), process.ArgsString(0), option)
+}
+
+func exchangeFS(name string) fs.FileSystem {
+	if name == "" {
+		name = "system"
+	}
+	return fs.MustGet(name)
+}
+
 // processPaginate 运行模型 MustPaginate
 func processPaginate(process *process.Process) interface{} {
 	process.ValidateArgNums(3)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	params, ok := AnyToQueryParam(process.Args[0])
 	if !ok {
 		exception.New("第1个查询参数错误 %v", 400, process.Args[0]).Throw()
@@ -72,7 +157,7 @@ func processPaginate(process *process.Process) interface{} {
 // processCreate 运行模型 MustCreate
 func processCreate(process *process.Process) interface{} {
 	process.ValidateArgNums(1)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	row := any.Of(process.Args[0]).Map().MapStrAny
 	return mod.MustCreate(row)
 }
@@ -80,7 +165,7 @@ func processCreate(process *process.Process) interface{} {
 // processUpdate 运行模型 MustUpdate
 func processUpdate(process *process.Process) interface{} {
 	process.ValidateArgNums(2)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	id := process.Args[0]
 	row := any.Of(process.Args[1]).Map().MapStrAny
 	mod.MustUpdate(id, row)
@@ -90,7 +175,7 @@ func processUpdate(process *process.Process) interface{} {
 // processSave 运行模型 MustSave
 func processSave(process *process.Process) interface{} {
 	process.ValidateArgNums(1)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	row := any.Of(process.Args[0]).Map().MapStrAny
 	return mod.MustSave(row)
 }
@@ -98,7 +183,7 @@ func processSave(process *process.Process) interface{} {
 // processDelete 运行模型 MustDelete
 func processDelete(process *process.Process) interface{} {
 	process.ValidateArgNums(1)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	mod.MustDelete(process.Args[0])
 	return nil
 }
@@ -106,7 +191,7 @@ func processDelete(process *process.Process) interface{} {
 // processDestroy 运行模型 MustDestroy
 func processDestroy(process *process.Process) interface{} {
 	process.ValidateArgNums(1)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	mod.MustDestroy(process.Args[0])
 	return nil
 }
@@ -114,44 +199,77 @@ func processDestroy(process *process.Process) interface{} {
 // processInsert 运行模型 MustInsert
 func processInsert(process *process.Process) interface{} {
 	process.ValidateArgNums(2)
-	mod := Select(process.ID)
-	var colums = []string{}
-	colums, ok := process.Args[0].([]string)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	mod.MustInsert(processColumns(process, 0), processRows(process, 1))
+	return nil
+}
+
+// processUpsert 运行模型 MustUpsert (columns, rows, uniqueBy, updateColumns)
+func processUpsert(process *process.Process) interface{} {
+	process.ValidateArgNums(3)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	columns := processColumns(process, 0)
+	rows := processRows(process, 1)
+
+	uniqueBy := []string{}
+	if name, ok := process.Args[2].(string); ok {
+		uniqueBy = append(uniqueBy, name)
+	} else {
+		uniqueBy = processColumns(process, 2)
+	}
+
+	updateColumns := []string{}
+	if process.NumOfArgs() > 3 && process.Args[3] != nil {
+		updateColumns = processColumns(process, 3)
+	}
+
+	return mod.MustUpsert(columns, rows, uniqueBy, updateColumns)
+}
+
+// processColumns 读取字段清单参数
+func processColumns(process *process.Process, i int) []string {
+	if columns, ok := process.Args[i].([]string); ok {
+		return columns
+	}
+
+	anyColumns, ok := process.Args[i].([]interface{})
 	if !ok {
-		anyColums, ok := process.Args[0].([]interface{})
-		if !ok {
-			exception.New("第1个查询参数错误 %v", 400, process.Args[0]).Throw()
-		}
-		for _, col := range anyColums {
-			colums = append(colums, string(str.Of(col)))
-		}
+		exception.New("第%d个查询参数错误 %v", 400, i+1, process.Args[i]).Throw()
+	}
+
+	columns := []string{}
+	for _, col := range anyColumns {
+		columns = append(columns, string(str.Of(col)))
+	}
+	return columns
+}
+
+// processRows 读取数据清单参数
+func processRows(process *process.Process, i int) [][]interface{} {
+	if rows, ok := process.Args[i].([][]interface{}); ok {
+		return rows
 	}
 
-	var rows = [][]interface{}{}
-	rows, ok = process.Args[1].([][]interface{})
+	anyRows, ok := process.Args[i].([]interface{})
 	if !ok {
-		anyRows, ok := process.Args[1].([]interface{})
-		if !ok {
-			exception.New("第2个查询参数错误 %v", 400, process.Args[1]).Throw()
-		}
-		for _, anyRow := range anyRows {
+		exception.New("第%d个查询参数错误 %v", 400, i+1, process.Args[i]).Throw()
+	}
 
-			row, ok := anyRow.([]interface{})
-			if !ok {
-				exception.New("第2个查询参数错误 %v", 400, process.Args[1]).Throw()
-			}
-			rows = append(rows, row)
+	rows := [][]interface{}{}
+	for _, anyRow := range anyRows {
+		row, ok := anyRow.([]interface{})
+		if !ok {
+			exception.New("第%d个查询参数错误 %v", 400, i+1, process.Args[i]).Throw()
 		}
+		rows = append(rows, row)
 	}
-
-	mod.MustInsert(colums, rows)
-	return nil
+	return rows
 }
 
 // processUpdateWhere 运行模型 MustUpdateWhere
 func processUpdateWhere(process *process.Process) interface{} {
 	process.ValidateArgNums(2)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	params, ok := AnyToQueryParam(process.Args[0])
 	if !ok {
 		exception.New("第1个查询参数错误 %v", 400, process.Args[0]).Throw()
@@ -163,7 +281,7 @@ func processUpdateWhere(process *process.Process) interface{} {
 // processDeleteWhere 运行模型 MustDeleteWhere
 func processDeleteWhere(process *process.Process) interface{} {
 	process.ValidateArgNums(1)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	params, ok := AnyToQueryParam(process.Args[0])
 	if !ok {
 		params = QueryParam{}
@@ -174,7 +292,7 @@ func processDeleteWhere(process *process.Process) interface{} {
 // processDestroyWhere 运行模型 MustDestroyWhere
 func processDestroyWhere(process *process.Process) interface{} {
 	process.ValidateArgNums(1)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	params, ok := AnyToQueryParam(process.Args[0])
 	if !ok {
 		params = QueryParam{}
@@ -182,10 +300,29 @@ func processDestroyWhere(process *process.Process) interface{} {
 	return mod.MustDestroyWhere(params)
 }
 
+// processRestore 运行模型 MustRestore
+func processRestore(process *process.Process) interface{} {
+	process.ValidateArgNums(1)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	mod.MustRestore(process.Args[0])
+	return nil
+}
+
+// processRestoreWhere 运行模型 MustRestoreWhere
+func processRestoreWhere(process *process.Process) interface{} {
+	process.ValidateArgNums(1)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	params, ok := AnyToQueryParam(process.Args[0])
+	if !ok {
+		params = QueryParam{}
+	}
+	return mod.MustRestoreWhere(params)
+}
+
 // processEachSave 运行模型 MustEachSave
 func processEachSave(process *process.Process) interface{} {
 	process.ValidateArgNums(1)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	rows := process.ArgsRecords(0)
 	eachrow := map[string]interface{}{}
 	if process.NumOfArgsIs(2) {
@@ -197,7 +334,7 @@ func processEachSave(process *process.Process) interface{} {
 // processEachSaveAfterDelete 运行模型 MustDeleteWhere 后 MustEachSave
 func processEachSaveAfterDelete(process *process.Process) interface{} {
 	process.ValidateArgNums(2)
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	eachrow := map[string]interface{}{}
 	ids := []int{}
 	if v, ok := process.Args[0].([]int); ok {
@@ -219,7 +356,7 @@ func processEachSaveAfterDelete(process *process.Process) interface{} {
 
 // processSelectOption 运行模型 MustGet
 func processSelectOption(process *process.Process) interface{} {
-	mod := Select(process.ID)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
 	keyword := "%%"
 	if process.NumOfArgs() > 0 {
 		keyword = fmt.Sprintf("%%%s%%", process.ArgsString(0))
@@ -259,3 +396,93 @@ func processSelectOption(process *process.Process) interface{} {
 
 	return res
 }
+
+// processHistory 读取数据变更记录
+func processHistory(process *process.Process) interface{} {
+	process.ValidateArgNums(1)
+	mod := Select(process.ID).WithSID(process.Sid).WithGlobal(process.Global)
+	logs, err := mod.History(process.Args[0])
+	if errors.Is(err, errHistoryNotFound) {
+		exception.Err(err, 404).Throw()
+	}
+	if err != nil {
+		exception.New("读取变更记录失败 %s", 500, err.Error()).Throw()
+	}
+	return logs
+}
+
+// processTransaction 在事务中运行处理器 models.Transaction, models.<id>.Transaction (使用模型的连接器)
+// args[0] 处理器名称 (如: flows.order.create), args[1:] 处理器参数; 或处理器列表 [{"process":"models.order.Create", "args":[...]}, ...]
+func processTransaction(process *process.Process) interface{} {
+	process.ValidateArgNums(1)
+	connector := ""
+	if process.ID != "" {
+		connector = Select(process.ID).MetaData.Connector
+	}
+
+	// 已在同一连接器的事务中
+	if tx := TransactionOf(process.Global); tx != nil && tx.Connector == ConnectorName(connector) {
+		return transactionRun(process, process.Global)
+	}
+
+	var res interface{}
+	var thrown interface{}
+	err := Transaction(connector, func(tx *Tx) (err error) {
+		defer func() {
+			if thrown = recover(); thrown != nil {
+				err = fmt.Errorf("%v", thrown)
+			}
+		}()
+		res = transactionRun(process, tx.Global(process.Global))
+		return nil
+	})
+
+	// 回滚后抛出原异常
+	if thrown != nil {
+		panic(thrown)
+	}
+
+	if err != nil {
+		exception.New("事务执行失败 %s", 500, err.Error()).Throw()
+	}
+	return res
+}
+
+// transactionRun 运行事务中的处理器
+func transactionRun(p *process.Process, global map[string]interface{}) interface{} {
+
+	if name, ok := p.Args[0].(string); ok {
+		return transactionProcess(p, global, name, p.Args[1:]...).Run()
+	}
+
+	calls, ok := p.Args[0].([]interface{})
+	if !ok {
+		exception.New("参数错误: 第1个参数应为处理器名称或处理器列表 %v", 400, p.Args[0]).Throw()
+	}
+
+	res := []interface{}{}
+	for i, call := range calls {
+		value, ok := call.(map[string]interface{})
+		if !ok {
+			exception.New("参数错误: 第%d个处理器格式错误 %v", 400, i+1, call).Throw()
+		}
+
+		name, ok := value["process"].(string)
+		if !ok || name == "" {
+			exception.New("参数错误: 第%d个处理器名称错误 %v", 400, i+1, value["process"]).Throw()
+		}
+
+		args, _ := value["args"].([]interface{})
+		res = append(res, transactionProcess(p, global, name, args...).Run())
+	}
+	return res
+}
+
+// transactionProcess make the process running in the transaction, the root privileges of the caller are kept
+func transactionProcess(p *process.Process, global map[string]interface{}, name string, args ...interface{}) *process.Process {
+	sub := process.New(name, args...).WithGlobal(global).WithSID(p.Sid)
+	if p.Root() {
+		sub.WithRoot(true)
+	}
+	return sub
+}
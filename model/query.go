@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/yaoapp/xun/capsule"
 	"github.com/yaoapp/xun/dbal/query"
 )
 
@@ -29,13 +28,14 @@ func (param QueryParam) Query(stack *QueryStack, stackParams ...QueryStackParam)
 	if param.Model == "" {
 		return stack
 	}
-	mod := Select(param.Model)
+	mod := param.model()
 	param.Table = mod.MetaData.Table.Name
 	if param.Alias == "" {
 		param.Alias = param.Table
 	}
 
 	exportPrefix := param.Export
+	joined := stack != nil
 	if stack == nil {
 		stack = MakeQueryStack()
 		stackParam := QueryStackParam{
@@ -47,7 +47,7 @@ func (param QueryParam) Query(stack *QueryStack, stackParams ...QueryStackParam)
 
 		builder := QueryStackBuilder{
 			Model:     mod,
-			Query:     capsule.Query().Table(param.Table + " as " + param.Alias),
+			Query:     mod.newQuery().Table(param.Table + " as " + param.Alias),
 			ColumnMap: map[string]ColumnMap{},
 		}
 
@@ -58,6 +58,9 @@ func (param QueryParam) Query(stack *QueryStack, stackParams ...QueryStackParam)
 	// Select
 	if len(param.Select) == 0 {
 		param.Select = mod.ColumnNames // Select All
+		if param.isAggregate() {
+			param.Select = param.groupSelect() // Select the group columns
+		}
 	}
 
 	selects := mod.Filterselect(param.Alias, param.Select, stack.Builder().ColumnMap, exportPrefix)
@@ -68,9 +71,23 @@ func (param QueryParam) Query(stack *QueryStack, stackParams ...QueryStackParam)
 		param.Where(where, stack.Query(), mod)
 	}
 
-	// 软删除
+	// 软删除 (默认排除已删除数据)
 	if mod.MetaData.Option.SoftDeletes {
-		param.Where(QueryWhere{Column: "deleted_at", OP: "null"}, stack.Query(), mod)
+		if param.OnlyTrashed {
+			param.Where(QueryWhere{Column: "deleted_at", OP: "notnull", internal: true}, stack.Query(), mod)
+		} else if !param.WithTrashed {
+			param.Where(QueryWhere{Column: "deleted_at", OP: "null", internal: true}, stack.Query(), mod)
+		}
+	}
+
+	// 租户 (关联模型的租户条件在 Join 子查询中过滤, 避免排除无关联数据的主表记录)
+	if id, scoped := mod.tenant(); scoped && !joined {
+		param.Where(QueryWhere{Column: mod.tenantColumn(), Value: id, internal: true}, stack.Query(), mod)
+	}
+
+	// Group & Aggregate
+	if param.isAggregate() {
+		param.aggregate(stack.Query(), mod)
 	}
 
 	// Order
@@ -99,18 +116,43 @@ func (param QueryParam) With(name string, stack *QueryStack, with With, mod *Mod
 	}
 
 	rel.Name = name
+
+	// 跨连接器的关联不能 Join, 使用独立查询
+	switch rel.Type {
+	case RelHasOne, RelBelongsTo, RelMorphOne:
+		if !mod.sameConnector(Select(rel.Model)) {
+			if rel.Type == RelMorphOne {
+				with = param.withMorph(rel, with)
+			}
+			param.withSeparate(stack, rel, with)
+			return
+		}
+	}
+
 	switch rel.Type {
-	case "hasOne":
+	case "hasOne", "belongsTo":
 		param.Export = rel.Name
 		param.withHasOne(stack, rel, with)
 		return
 	case "hasOneThrough":
+		for _, link := range rel.Links {
+			mod.joinable(name, Select(link.Model))
+		}
 		param.withHasOneThrough(stack, rel, with)
 		return
 	case "hasMany":
 		param.withHasMany(stack, rel, with)
 		return
-
+	case "morphOne":
+		param.Export = rel.Name
+		param.withHasOne(stack, rel, param.withMorph(rel, with))
+		return
+	case "morphMany":
+		param.withHasMany(stack, rel, param.withMorph(rel, with))
+		return
+	case "belongsToMany", "morphToMany", "morphByMany":
+		param.withBelongsToMany(stack, rel, with)
+		return
 	}
 
 }
@@ -141,6 +183,7 @@ func (param QueryParam) withHasOne(stack *QueryStack, rel Relation, with With) {
 	withModel := Select(rel.Model)
 	withParam := with.Query
 	withParam.Model = rel.Model
+	withParam.inherit(param)
 	withParam.Table = withModel.MetaData.Table.Name
 	withParam.Alias = withParam.Table + "__rel__" // 临时BUG修复，这里整个逻辑需要优化
 	if param.Alias != "" {
@@ -169,14 +212,16 @@ func (param QueryParam) withHasOne(stack *QueryStack, rel Relation, with With) {
 	}
 
 	if len(withParam.Wheres) == 0 && len(rel.Query.Wheres) > 0 {
-		withParam.Wheres = rel.Query.Wheres
+		withParam.Wheres = internalWheres(rel.Query.Wheres)
 	}
 
 	if len(withParam.Select) == 0 && len(rel.Query.Select) > 0 {
 		withParam.Select = rel.Query.Select
 	}
 
-	if len(withParam.Wheres) > 0 || len(withParam.Orders) > 0 {
+	// the tenant of the related model is filtered in the sub query
+	tenantID, tenantScoped := withParam.model().tenant()
+	if len(withParam.Wheres) > 0 || len(withParam.Orders) > 0 || tenantScoped {
 
 		withSubParam := withParam
 		withSubParam.Alias = withParam.Table
@@ -187,8 +232,8 @@ func (param QueryParam) withHasOne(stack *QueryStack, rel Relation, with With) {
 			sub.Table(withSubParam.Table)
 
 			// Select
-			if len(withParam.Select) == 0 {
-				withSubParam.Select = withModel.ColumnNames // Select All
+			if len(withParam.Select) == 0 || withModel.virtualSelected(withParam.Select) {
+				withSubParam.Select = withModel.ColumnNames // Select All, the virtual expressions are computed from the columns of the sub query
 			} else if !withParam.hasSelectColumn(rel.Key) {
 				withSubParam.Select = append(withParam.Select, rel.Key)
 			}
@@ -203,7 +248,12 @@ func (param QueryParam) withHasOne(stack *QueryStack, rel Relation, with With) {
 
 			// Where
 			for _, where := range withSubParam.Wheres {
-				withSubParam.Where(where, sub, withModel)
+				withSubParam.Where(where, sub, withParam.model())
+			}
+
+			// 租户
+			if tenantScoped {
+				sub.Where(withModel.tenantColumn(), tenantID)
 			}
 		}, withParam.Alias, key, "=", foreign)
 
@@ -256,7 +306,8 @@ func (param QueryParam) Order(order QueryOrder, qb query.Query, mod *Model) {
 			if param.Alias != "" {
 				alias = param.Alias + "_" + alias
 			}
-			m = Select(rel.Model)
+			m = param.relModel(rel.Model)
+			mod.joinable(order.Rel, m)
 
 		} else { // manu
 			rel, has := mod.MetaData.Relations[order.Rel]
@@ -269,11 +320,15 @@ func (param QueryParam) Order(order QueryOrder, qb query.Query, mod *Model) {
 				alias = param.Alias + "_" + alias
 			}
 
-			m = Select(rel.Model)
+			m = param.relModel(rel.Model)
+			mod.joinable(order.Rel, m)
 		}
 
 	}
 
+	// 排序字段需有读权限
+	m.checkReadable(m.acl(), order.Column)
+
 	if order.Option == "" {
 		order.Option = "asc"
 	}
@@ -314,7 +369,8 @@ func (param QueryParam) Where(where QueryWhere, qb query.Query, mod *Model) {
 			if param.Alias != "" {
 				alias = param.Alias + "_" + alias
 			}
-			m = Select(rel.Model)
+			m = param.relModel(rel.Model)
+			mod.joinable(where.Rel, m)
 
 		} else { // manu
 			rel, has := mod.MetaData.Relations[where.Rel]
@@ -322,16 +378,30 @@ func (param QueryParam) Where(where QueryWhere, qb query.Query, mod *Model) {
 				return
 			}
 
+			mod.joinable(where.Rel, Select(rel.Model))
+
+			// The relation is not joined, filter with the exists sub query
+			if !isJoinedRel(rel) {
+				rel.Name = where.Rel
+				param.whereRel(where, rel, qb)
+				return
+			}
+
 			alias = where.Rel + "__rel__" //  这里逻辑需要重构
 			if param.Alias != "" {
 				alias = param.Alias + "_" + alias
 			}
 
-			m = Select(rel.Model)
+			m = param.relModel(rel.Model)
 		}
 
 	}
 
+	// 查询条件字段需有读权限
+	if name, ok := where.Column.(string); ok && name != "" && !where.internal {
+		m.checkReadable(m.acl(), name)
+	}
+
 	if where.Method == "" {
 		where.Method = "where"
 	}
@@ -410,6 +480,7 @@ func (param QueryParam) withHasMany(stack *QueryStack, rel Relation, with With)
 	withModel := Select(rel.Model)
 	withParam := with.Query
 	withParam.Model = rel.Model
+	withParam.inherit(param)
 	withParam.Table = withModel.MetaData.Table.Name
 	withParam.Alias = withParam.Table
 	withParam.Alias = withParam.Table
@@ -439,6 +510,15 @@ func (param QueryParam) withHasMany(stack *QueryStack, rel Relation, with With)
 	stack.Merge(newStack)
 }
 
+// withSeparate 跨连接器关联查询, 与 hasMany 相同使用独立查询, 结果为单条记录
+func (param QueryParam) withSeparate(stack *QueryStack, rel Relation, with With) {
+	offset := stack.Len()
+	param.withHasMany(stack, rel, with)
+	if offset < stack.Len() {
+		stack.Params[offset].Separate = true
+	}
+}
+
 // hasSelectColumn 检查字段是否已存在
 func (param QueryParam) hasSelectColumn(column interface{}) bool {
 	for _, col := range param.Select {
@@ -448,3 +528,52 @@ func (param QueryParam) hasSelectColumn(column interface{}) bool {
 	}
 	return false
 }
+
+// bind bind the context of the model (transaction, session and global vars) to the query param
+func (param *QueryParam) bind(mod *Model) {
+	param.tx = mod.tx
+	param.sid = mod.sid
+	param.global = mod.global
+}
+
+// inherit inherit the context of the parent query param
+func (param *QueryParam) inherit(parent QueryParam) {
+	param.tx = parent.tx
+	param.sid = parent.sid
+	param.global = parent.global
+}
+
+// relModel select the related model with the context of the query param
+func (param QueryParam) relModel(name string) *Model {
+	relParam := QueryParam{Model: name}
+	relParam.inherit(param)
+	return relParam.model()
+}
+
+// internalWheres mark the conditions defined by the model, the column permissions of the caller are not checked
+func internalWheres(wheres []QueryWhere) []QueryWhere {
+	res := make([]QueryWhere, len(wheres))
+	for i, where := range wheres {
+		where.internal = true
+		if where.Wheres != nil {
+			where.Wheres = internalWheres(where.Wheres)
+		}
+		res[i] = where
+	}
+	return res
+}
+
+// model select the model of the query param with the context
+func (param QueryParam) model() *Model {
+	mod := Select(param.Model).withTx(param.tx)
+	if param.sid == "" && param.global == nil {
+		return mod
+	}
+
+	new := *mod
+	new.sid = param.sid
+	if param.global != nil {
+		new.global = param.global
+	}
+	return &new
+}
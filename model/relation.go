@@ -0,0 +1,262 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/dbal"
+	"github.com/yaoapp/xun/dbal/query"
+)
+
+// pivotForeign the alias of the pivot foreign column
+const pivotForeign = "__pivot_foreign"
+
+// pivotPrefix the alias prefix of the pivot columns
+const pivotPrefix = "__pivot_"
+
+// withMorph add the morph type condition to the with query
+func (param QueryParam) withMorph(rel Relation, with With) With {
+	if rel.Morph == nil {
+		exception.New("relation %s: morph is required", 400, rel.Name).Throw()
+	}
+
+	wheres := with.Query.Wheres
+	if len(wheres) == 0 {
+		wheres = internalWheres(rel.Query.Wheres)
+	}
+
+	with.Query.Wheres = append([]QueryWhere{}, wheres...)
+	with.Query.Wheres = append(with.Query.Wheres, QueryWhere{Column: rel.Morph.Column, Value: param.morphValue(rel), internal: true})
+	return with
+}
+
+// withBelongsToMany belongsToMany, morphToMany, morphByMany 关联查询
+func (param QueryParam) withBelongsToMany(stack *QueryStack, rel Relation, with With) {
+	if rel.Pivot == nil {
+		exception.New("relation %s: pivot is required", 400, rel.Name).Throw()
+	}
+
+	withModel := Select(rel.Model)
+	withParam := with.Query
+	withParam.Model = rel.Model
+	withParam.inherit(param)
+	withParam.Table = withModel.MetaData.Table.Name
+	withParam.Alias = withParam.Table
+	if param.Alias != "" {
+		withParam.Alias = param.Alias + "_" + withParam.Alias
+	}
+
+	if len(withParam.Wheres) == 0 && len(rel.Query.Wheres) > 0 {
+		withParam.Wheres = internalWheres(rel.Query.Wheres)
+	}
+
+	if len(withParam.Select) == 0 {
+		withParam.Select = withModel.ColumnNames // Select all
+	}
+
+	// 添加关联外键
+	if !param.hasSelectColumn(rel.Foreign) {
+		mod := Select(param.Model)
+		selects := mod.Filterselect(param.Alias, []interface{}{rel.Foreign}, stack.Builder().ColumnMap, "")
+		stack.Query().SelectAppend(selects...)
+	}
+
+	stackParam := QueryStackParam{
+		QueryParam: withParam,
+		Relation:   rel,
+	}
+	newStack := withParam.Query(nil, stackParam)
+
+	// Join the pivot table
+	pivot := withParam.pivotAlias()
+	qb := newStack.FirstQuery()
+	qb.Join(rel.Pivot.Table+" as "+pivot, pivot+"."+rel.Pivot.Key, "=", withParam.Alias+"."+rel.Key)
+	qb.SelectAppend(fmt.Sprintf("%s.%s as %s", pivot, rel.Pivot.Foreign, pivotForeign))
+	for _, column := range rel.Pivot.Columns {
+		qb.SelectAppend(fmt.Sprintf("%s.%s as %s%s", pivot, column, pivotPrefix, column))
+	}
+
+	if rel.Type == RelMorphToMany || rel.Type == RelMorphByMany {
+		if rel.Morph == nil {
+			exception.New("relation %s: morph is required", 400, rel.Name).Throw()
+		}
+		qb.Where(pivot+"."+rel.Morph.Column, param.morphValue(rel))
+	}
+
+	stack.Merge(newStack)
+}
+
+// whereRel filter the rows by the relation which is not joined (hasMany, belongsToMany, morph...) with the exists sub query
+func (param QueryParam) whereRel(where QueryWhere, rel Relation, qb query.Query) {
+
+	relParam := QueryParam{
+		Model: rel.Model,
+		Alias: param.Alias + "_" + rel.Name + "__exists__",
+	}
+
+	// the sub query runs with the session and the global vars of the caller (tenant, permissions)
+	relParam.inherit(param)
+	relModel := relParam.model()
+	relParam.Table = relModel.MetaData.Table.Name
+
+	method := strings.ToLower(where.Method)
+	where.Rel = ""
+	where.Method = "where"
+
+	exists := func(sub query.Query) {
+		sub.Table(relParam.Table + " as " + relParam.Alias)
+		sub.Select(dbal.Raw("1"))
+
+		switch rel.Type {
+		case RelBelongsToMany, RelMorphToMany, RelMorphByMany:
+			if rel.Pivot == nil {
+				exception.New("relation %s: pivot is required", 400, rel.Name).Throw()
+			}
+			pivot := relParam.pivotAlias()
+			sub.Join(rel.Pivot.Table+" as "+pivot, pivot+"."+rel.Pivot.Key, "=", relParam.Alias+"."+rel.Key)
+			sub.WhereColumn(pivot+"."+rel.Pivot.Foreign, "=", param.Alias+"."+rel.Foreign)
+			if rel.Type != RelBelongsToMany {
+				if rel.Morph == nil {
+					exception.New("relation %s: morph is required", 400, rel.Name).Throw()
+				}
+				sub.Where(pivot+"."+rel.Morph.Column, param.morphValue(rel))
+			}
+
+		default: // hasMany, morphOne, morphMany
+			sub.WhereColumn(relParam.Alias+"."+rel.Key, "=", param.Alias+"."+rel.Foreign)
+			if rel.Type == RelMorphOne || rel.Type == RelMorphMany {
+				if rel.Morph == nil {
+					exception.New("relation %s: morph is required", 400, rel.Name).Throw()
+				}
+				sub.Where(relParam.Alias+"."+rel.Morph.Column, param.morphValue(rel))
+			}
+		}
+
+		// 软删除
+		if relModel.MetaData.Option.SoftDeletes {
+			sub.WhereNull(relParam.Alias + ".deleted_at")
+		}
+
+		// 租户
+		if id, scoped := relModel.tenant(); scoped {
+			sub.Where(relParam.Alias+"."+relModel.tenantColumn(), id)
+		}
+
+		relParam.Where(where, sub, relModel)
+	}
+
+	if method == "orwhere" {
+		qb.OrWhereExists(exists)
+		return
+	}
+	qb.WhereExists(exists)
+}
+
+// morphValue the morph type value of the relation
+func (param QueryParam) morphValue(rel Relation) string {
+	if rel.Morph.Value != "" {
+		return rel.Morph.Value
+	}
+
+	if rel.Type == RelMorphByMany {
+		return rel.Model
+	}
+	return param.Model
+}
+
+// pivotAlias the alias of the pivot table
+func (param QueryParam) pivotAlias() string {
+	return param.Alias + "__pivot__"
+}
+
+// isJoinedRel check if the relation is joined to the main query (hasOne, hasOneThrough, belongsTo)
+func isJoinedRel(rel Relation) bool {
+	switch rel.Type {
+	case RelHasMany, RelBelongsToMany, RelMorphOne, RelMorphMany, RelMorphToMany, RelMorphByMany:
+		return false
+	}
+	return true
+}
+
+// runBelongsToMany belongsToMany, morphToMany, morphByMany 查询
+func (stack *QueryStack) runBelongsToMany(res *[][]maps.MapStrAny, builder QueryStackBuilder, param QueryStackParam) {
+
+	// 获取上级查询结果，拼接结果集ID
+	rel := param.Relation
+	foreignIDs := []interface{}{}
+	prevRows := (*res)[param.Parent]
+	for _, row := range prevRows {
+		if id := row.Get(rel.Foreign); id != nil {
+			foreignIDs = append(foreignIDs, id)
+		}
+	}
+
+	varname := rel.Name
+	for idx := range prevRows {
+		prevRows[idx][varname] = []maps.MapStr{}
+	}
+
+	// 空数据
+	if len(foreignIDs) == 0 {
+		*res = append(*res, []maps.MapStr{})
+		return
+	}
+
+	builder.Query.WhereIn(param.QueryParam.pivotAlias()+"."+rel.Pivot.Foreign, foreignIDs)
+	if param.QueryParam.Limit > 0 {
+		builder.Query.Limit(param.QueryParam.Limit)
+	}
+	rows := builder.Query.MustGet()
+
+	// 格式化数据
+	fmtRowMap := map[interface{}][]maps.MapStr{}
+	fmtRows := []maps.MapStr{}
+	for _, row := range rows {
+		fmtRow := maps.MapStr{}
+		pivot := maps.MapStr{}
+		var relVal interface{}
+		for key, value := range row {
+			if key == pivotForeign {
+				relVal = value
+				continue
+			}
+
+			if strings.HasPrefix(key, pivotPrefix) {
+				pivot[strings.TrimPrefix(key, pivotPrefix)] = value
+				continue
+			}
+
+			if cmap, has := builder.ColumnMap[key]; has {
+				fmtRow[cmap.Export] = value
+				cmap.Column.FliterOut(value, fmtRow, cmap.Export)
+				continue
+			}
+			fmtRow[key] = value
+		}
+		computeRow(fmtRow, builder.ColumnMap)
+
+		if relVal == nil {
+			continue
+		}
+
+		unDotRow := fmtRow.UnDot()
+		if len(rel.Pivot.Columns) > 0 {
+			unDotRow["pivot"] = pivot
+		}
+		fmtRows = append(fmtRows, unDotRow)
+		key := fmt.Sprintf("%v", relVal)
+		fmtRowMap[key] = append(fmtRowMap[key], unDotRow)
+	}
+
+	// 追加到上级查询
+	for idx, prow := range prevRows {
+		key := fmt.Sprintf("%v", prow.Get(rel.Foreign))
+		if rows, has := fmtRowMap[key]; has {
+			prevRows[idx][varname] = rows
+		}
+	}
+
+	*res = append(*res, fmtRows)
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestRelationBelongsTo(t *testing.T) {
+	dbconnect(t)
+	prepareTests(t, "tests.rel.user", "tests.rel.role", "tests.rel.user_role", "tests.rel.comment", "tests.rel.note")
+	defer clean()
+
+	rows := Select("tests.rel.comment").MustGet(QueryParam{
+		Withs:  map[string]With{"author": {}},
+		Orders: []QueryOrder{{Column: "id"}},
+	})
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "Alice", maps.Of(rows[0]).Dot().Get("author.name"))
+	assert.Equal(t, "Bob", maps.Of(rows[2]).Dot().Get("author.name"))
+}
+
+func TestRelationBelongsToMany(t *testing.T) {
+	dbconnect(t)
+	prepareTests(t, "tests.rel.user", "tests.rel.role", "tests.rel.user_role", "tests.rel.comment", "tests.rel.note")
+	defer clean()
+
+	rows := Select("tests.rel.user").MustGet(QueryParam{
+		Withs:  map[string]With{"roles": {}},
+		Orders: []QueryOrder{{Column: "id"}},
+	})
+	assert.Len(t, rows, 2)
+
+	roles, ok := rows[0]["roles"].([]maps.MapStr)
+	assert.True(t, ok)
+	assert.Len(t, roles, 2)
+	assert.Equal(t, "2030-01-01", maps.Of(roles[0]).Dot().Get("pivot.expired_at"))
+
+	roles, ok = rows[1]["roles"].([]maps.MapStr)
+	assert.True(t, ok)
+	assert.Len(t, roles, 1)
+	assert.Equal(t, "editor", roles[0]["name"])
+}
+
+func TestRelationMorph(t *testing.T) {
+	dbconnect(t)
+	prepareTests(t, "tests.rel.user", "tests.rel.role", "tests.rel.user_role", "tests.rel.comment", "tests.rel.note")
+	defer clean()
+
+	rows := Select("tests.rel.user").MustGet(QueryParam{
+		Withs:  map[string]With{"comments": {}},
+		Orders: []QueryOrder{{Column: "id"}},
+	})
+	comments, ok := rows[0]["comments"].([]maps.MapStr)
+	assert.True(t, ok)
+	assert.Len(t, comments, 1)
+	assert.Equal(t, "on alice", comments[0]["content"])
+}
+
+func TestRelationWhereRel(t *testing.T) {
+	dbconnect(t)
+	prepareTests(t, "tests.rel.user", "tests.rel.role", "tests.rel.user_role", "tests.rel.comment", "tests.rel.note")
+	defer clean()
+
+	rows := Select("tests.rel.user").MustGet(QueryParam{
+		Wheres: []QueryWhere{{Rel: "roles", Column: "name", Value: "admin"}},
+	})
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "Alice", rows[0]["name"])
+
+	rows = Select("tests.rel.user").MustGet(QueryParam{
+		Wheres: []QueryWhere{{Rel: "comments", Column: "content", OP: "match", Value: "bob"}},
+	})
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "Bob", rows[0]["name"])
+}
+
+func TestRelationWhereRelContext(t *testing.T) {
+	dbconnect(t)
+	prepareTests(t, "tests.rel.user", "tests.rel.role", "tests.rel.user_role", "tests.rel.comment", "tests.rel.note")
+	defer clean()
+
+	note := Select("tests.rel.note")
+	note.WithGlobal(map[string]interface{}{"tenant": 1}).MustCreate(maps.MapStrAny{"user_id": 1, "content": "todo"})
+	note.WithGlobal(map[string]interface{}{"tenant": 2}).MustCreate(maps.MapStrAny{"user_id": 2, "content": "todo"})
+
+	// the exists sub query is scoped by the tenant of the caller
+	rows := Select("tests.rel.user").WithGlobal(map[string]interface{}{"tenant": 1}).MustGet(QueryParam{
+		Wheres: []QueryWhere{{Rel: "notes", Column: "content", Value: "todo"}},
+	})
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "Alice", rows[0]["name"])
+
+	assert.Panics(t, func() {
+		Select("tests.rel.user").MustGet(QueryParam{Wheres: []QueryWhere{{Rel: "notes", Column: "content", Value: "todo"}}})
+	})
+}
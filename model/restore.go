@@ -0,0 +1,155 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/dbal"
+)
+
+// errRestoreNotFound the trashed row is not found
+var errRestoreNotFound = errors.New("the trashed row is not found")
+
+// Restore 恢复单条软删除数据
+func (mod *Model) Restore(id interface{}) error {
+	effect, err := mod.RestoreWhere(QueryParam{
+		Wheres: []QueryWhere{{Column: mod.PrimaryKey, Value: id}},
+		Limit:  1,
+	})
+	if err != nil {
+		return err
+	}
+
+	if effect == 0 {
+		return fmt.Errorf("%s %v: %w", mod.ID, id, errRestoreNotFound)
+	}
+	return nil
+}
+
+// MustRestore 恢复单条软删除数据, 失败抛出异常 (数据不存在或未删除返回 404)
+func (mod *Model) MustRestore(id interface{}) {
+	err := mod.Restore(id)
+	if errors.Is(err, errRestoreNotFound) {
+		exception.Err(err, 404).Throw()
+	}
+
+	if err != nil {
+		exception.Err(err, 500).Throw()
+	}
+}
+
+// RestoreWhere 按条件恢复软删除数据, 返回恢复行数
+func (mod *Model) RestoreWhere(param QueryParam) (int, error) {
+	if !mod.MetaData.Option.SoftDeletes {
+		return 0, fmt.Errorf("%s does not enable the soft_deletes option", mod.ID)
+	}
+
+	param.WithTrashed = false
+	param.OnlyTrashed = true
+	olds := mod.auditFind(param)
+
+	param.Model = mod.Name
+	param.bind(mod)
+	stack := NewQueryStack(param)
+	qb := stack.FirstQuery()
+
+	data := maps.MapStrAny{"deleted_at": nil}
+	if mod.MetaData.Option.Trackings {
+		data["deleted_by"] = nil
+	}
+
+	// 还原删除时改写的唯一字段
+	uniques := mod.restoreUnique()
+	for name, value := range uniques {
+		data[name] = value
+	}
+
+	// 如果不是 SQLite3 添加字段
+	if mod.Driver != "sqlite3" {
+		fields := maps.MapStrAny{}
+		for name, value := range data {
+			fields[fmt.Sprintf("%s.%s", mod.MetaData.Table.Name, name)] = value
+		}
+		data = fields
+	}
+
+	effect, err := qb.Update(data)
+	if err != nil {
+		return 0, err
+	}
+
+	mod.restoreAudit(olds, uniques)
+	return int(effect), nil
+}
+
+// restoreAudit write the update logs of the restored rows, the restored unique values are included
+func (mod *Model) restoreAudit(olds []maps.MapStr, uniques map[string]interface{}) {
+	if len(olds) == 0 {
+		return
+	}
+
+	news := map[string]maps.MapStr{}
+	if len(uniques) > 0 {
+		ids := []interface{}{}
+		for _, row := range olds {
+			ids = append(ids, row.Get(mod.PrimaryKey))
+		}
+
+		rows := mod.auditFind(QueryParam{Wheres: []QueryWhere{{Column: mod.PrimaryKey, OP: "in", Value: ids}}, WithTrashed: true})
+		for _, row := range rows {
+			news[fmt.Sprintf("%v", row.Get(mod.PrimaryKey))] = row
+		}
+	}
+
+	for _, old := range olds {
+		key := old.Get(mod.PrimaryKey)
+		changes := maps.MapStrAny{"deleted_at": nil}
+		if row, has := news[fmt.Sprintf("%v", key)]; has {
+			for name := range uniques {
+				changes[name] = row.Get(name)
+			}
+		}
+		mod.audit(AuditUpdate, key, old, changes)
+	}
+}
+
+// MustRestoreWhere 按条件恢复软删除数据, 返回恢复行数, 失败抛出异常
+func (mod *Model) MustRestoreWhere(param QueryParam) int {
+	effect, err := mod.RestoreWhere(param)
+	if err != nil {
+		exception.Err(err, 500).Throw()
+	}
+	return effect
+}
+
+// restoreUnique the expressions to restore the unique columns changed by DeleteWhere
+func (mod *Model) restoreUnique() map[string]interface{} {
+	res := map[string]interface{}{}
+	for _, col := range mod.UniqueColumns {
+		typ := strings.ToLower(col.Type)
+		switch mod.Driver {
+
+		// '_' || value || UnixNano
+		case "sqlite3":
+			if typ == "string" {
+				res[col.Name] = dbal.Raw(fmt.Sprintf(
+					"CASE WHEN substr(%s, 1, 1) = '_' THEN substr(%s, 2, length(%s) - 20) ELSE %s END",
+					col.Name, col.Name, col.Name, col.Name,
+				))
+			}
+
+		// the values are saved in __restore_data
+		case "mysql":
+			if typ == "string" || col.Nullable {
+				res[col.Name] = dbal.Raw(fmt.Sprintf(
+					"COALESCE(JSON_UNQUOTE(JSON_EXTRACT(`__restore_data`, '$.%s')), `%s`)",
+					col.Name, col.Name,
+				))
+			}
+		}
+	}
+	return res
+}
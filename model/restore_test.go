@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestRestoreTrashed(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.restore", 5)
+
+	mod.MustDelete(1)
+	mod.MustDeleteWhere(QueryParam{Wheres: []QueryWhere{{Column: "score", OP: "ge", Value: 3}}})
+
+	assert.Len(t, mod.MustGet(QueryParam{}), 2)
+	assert.Len(t, mod.MustGet(QueryParam{WithTrashed: true}), 5)
+	assert.Len(t, mod.MustGet(QueryParam{OnlyTrashed: true}), 3)
+	assert.Equal(t, 2, any.Of(mod.MustPaginate(QueryParam{}, 1, 10)["total"]).CInt())
+
+	// restore
+	mod.MustRestore(1)
+	row := mod.MustFind(1, QueryParam{})
+	assert.Nil(t, row.Get("deleted_at"))
+	assert.Equal(t, "user-0@test.com", row.Get("email"))
+
+	// the row is not trashed
+	assert.Equal(t, 404, exceptionCode(func() { mod.MustRestore(1) }))
+	assert.Equal(t, 404, exceptionCode(func() { mod.MustRestore(9999) }))
+
+	assert.Equal(t, 2, mod.MustRestoreWhere(QueryParam{Wheres: []QueryWhere{{Column: "score", OP: "ge", Value: 3}}}))
+	assert.Len(t, mod.MustGet(QueryParam{}), 5)
+	assert.Len(t, mod.MustGet(QueryParam{OnlyTrashed: true}), 0)
+}
+
+func TestRestoreProcess(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.restore", 5)
+	mod.MustDeleteWhere(QueryParam{})
+
+	process.New("models.tests.restore.Restore", 2).Run()
+	rows := process.New("models.tests.restore.Get", map[string]interface{}{"onlyTrashed": true}).Run().([]maps.MapStr)
+	assert.Len(t, rows, 4)
+
+	effect := process.New("models.tests.restore.RestoreWhere", map[string]interface{}{
+		"wheres": []map[string]interface{}{{"column": "score", "op": "lt", "value": 3}},
+	}).Run()
+	assert.Equal(t, 2, effect)
+	assert.Len(t, mod.MustGet(QueryParam{}), 3)
+}
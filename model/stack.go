@@ -26,6 +26,8 @@ type QueryStackParam struct {
 	QueryParam   QueryParam
 	Relation     Relation
 	ExportPrefix string // 字段导出前缀
+	Parent       int    // the index of the parent query, the results are attached to it
+	Separate     bool   // the relation is resolved with a separate query (the related model is on another connector)
 }
 
 // MakeQueryStack 创建查询栈
@@ -52,9 +54,15 @@ func (stack *QueryStack) Push(builder QueryStackBuilder, param QueryStackParam)
 // Merge 合并 Stack
 func (stack *QueryStack) Merge(new *QueryStack) {
 	curr := stack.Current
+	offset := len(stack.Builders)
 	for i, builder := range new.Builders {
+		param := new.Params[i]
+		param.Parent = param.Parent + offset
+		if i == 0 {
+			param.Parent = curr
+		}
 		stack.Builders = append(stack.Builders, builder)
-		stack.Params = append(stack.Params, new.Params[i])
+		stack.Params = append(stack.Params, param)
 	}
 	stack.Current = curr
 }
@@ -119,10 +127,17 @@ func (stack *QueryStack) Run() []maps.MapStrAny {
 	res := [][]maps.MapStrAny{}
 	for i, qb := range stack.Builders {
 		param := stack.Params[i]
+		if param.Separate {
+			stack.runHasMany(&res, qb, param)
+			continue
+		}
 		switch param.Relation.Type {
-		case "hasMany":
+		case "hasMany", "morphMany":
 			stack.runHasMany(&res, qb, param)
 			break
+		case "belongsToMany", "morphToMany", "morphByMany":
+			stack.runBelongsToMany(&res, qb, param)
+			break
 		default:
 			stack.run(&res, qb, param)
 		}
@@ -144,10 +159,17 @@ func (stack *QueryStack) Paginate(page int, pagesize int) maps.MapStrAny {
 			pageInfo = stack.paginate(page, pagesize, &res, qb, param)
 			continue
 		}
+		if param.Separate {
+			stack.runHasMany(&res, qb, param)
+			continue
+		}
 		switch param.Relation.Type {
-		case "hasMany":
+		case "hasMany", "morphMany":
 			stack.runHasMany(&res, qb, param)
 			break
+		case "belongsToMany", "morphToMany", "morphByMany":
+			stack.runBelongsToMany(&res, qb, param)
+			break
 		default:
 			stack.run(&res, qb, param)
 		}
@@ -189,6 +211,7 @@ func (stack *QueryStack) paginate(page int, pagesize int, res *[][]maps.MapStrAn
 			fmtRow[key] = value
 		}
 
+		computeRow(fmtRow, builder.ColumnMap)
 		fmtRows = append(fmtRows, fmtRow.UnDot())
 	}
 	*res = append(*res, fmtRows)
@@ -221,6 +244,7 @@ func (stack *QueryStack) run(res *[][]maps.MapStrAny, builder QueryStackBuilder,
 			}
 			fmtRow[key] = value
 		}
+		computeRow(fmtRow, builder.ColumnMap)
 		fmtRows = append(fmtRows, fmtRow.UnDot())
 	}
 	*res = append(*res, fmtRows)
@@ -229,15 +253,18 @@ func (stack *QueryStack) run(res *[][]maps.MapStrAny, builder QueryStackBuilder,
 
 func (stack *QueryStack) runHasMany(res *[][]maps.MapStrAny, builder QueryStackBuilder, param QueryStackParam) {
 
-	// 获取上次查询结果，拼接结果集ID
-	rel := stack.Relation()
+	// 获取上级查询结果，拼接结果集ID
+	rel := param.Relation
 	foreignIDs := []interface{}{}
-	prevRows := (*res)[len(*res)-1]
+	prevRows := (*res)[param.Parent]
 	for _, row := range prevRows {
 		id := row.Get(rel.Foreign)
 		foreignIDs = append(foreignIDs, id)
 	}
 
+	// 单条关联记录 (跨连接器的 hasOne, belongsTo, morphOne)
+	one := param.Separate && rel.Type != RelHasMany && rel.Type != RelMorphMany
+
 	// 添加 WhereIn 查询数据
 	name := rel.Key
 	if param.QueryParam.Alias != "" {
@@ -249,6 +276,10 @@ func (stack *QueryStack) runHasMany(res *[][]maps.MapStrAny, builder QueryStackB
 		*res = append(*res, []maps.MapStr{})
 		varname := rel.Name
 		for idx := range prevRows {
+			if one {
+				prevRows[idx][varname] = nil
+				continue
+			}
 			prevRows[idx][varname] = []maps.MapStr{}
 		}
 		return
@@ -257,6 +288,8 @@ func (stack *QueryStack) runHasMany(res *[][]maps.MapStrAny, builder QueryStackB
 	limit := 100
 	if param.QueryParam.Limit > 0 {
 		limit = param.QueryParam.Limit
+	} else if one && limit < len(foreignIDs) {
+		limit = len(foreignIDs)
 	}
 	builder.Query.WhereIn(name, foreignIDs).Limit(limit)
 	rows := builder.Query.MustGet()
@@ -274,6 +307,7 @@ func (stack *QueryStack) runHasMany(res *[][]maps.MapStrAny, builder QueryStackB
 			}
 			fmtRow[key] = value
 		}
+		computeRow(fmtRow, builder.ColumnMap)
 		relKey := rel.Key
 		relVal := fmtRow.Get(relKey)
 		if relVal != nil {
@@ -291,6 +325,14 @@ func (stack *QueryStack) runHasMany(res *[][]maps.MapStrAny, builder QueryStackB
 	// utils.Dump(fmtRows, rel.Foreign, varname, fmtRowMap, prevRows)
 	for idx, prow := range prevRows {
 		id := prow.Get(rel.Foreign)
+		if one {
+			prevRows[idx][varname] = nil
+			if rows, has := fmtRowMap[id]; has && len(rows) > 0 {
+				prevRows[idx][varname] = rows[0]
+			}
+			continue
+		}
+
 		if rows, has := fmtRowMap[id]; has {
 			if _, has := prevRows[idx][varname]; !has {
 				prevRows[idx][varname] = []maps.MapStr{}
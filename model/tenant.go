@@ -0,0 +1,134 @@
+package model
+
+import (
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/gou/session"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/log"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/dbal/query"
+)
+
+// TenantColumn the default tenant column of the models with the tenant option
+var TenantColumn = "tenant_id"
+
+// TenantSessionKey the default session key of the tenant id
+var TenantSessionKey = "tenant_id"
+
+// tenantColumn the tenant column of the model, return empty if the model does not enable the tenant option
+func (mod *Model) tenantColumn() string {
+	if mod.MetaData.Option.Tenant == nil {
+		return ""
+	}
+
+	if mod.MetaData.Option.Tenant.Column != "" {
+		return mod.MetaData.Option.Tenant.Column
+	}
+	return TenantColumn
+}
+
+// WithRoot bypass the tenant scoping with the root privileges, return a copy of the model
+func (mod *Model) WithRoot() *Model {
+	global := map[string]interface{}{}
+	for key, value := range mod.global {
+		global[key] = value
+	}
+	global[process.RootKey] = true
+
+	new := *mod
+	new.global = global
+	return &new
+}
+
+// hasMetaColumn check if the column is defined
+func (mod *Model) hasMetaColumn(name string) bool {
+	for _, column := range mod.MetaData.Columns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tenant resolve the tenant id of the caller (the global var first, then the session).
+// scoped is false if the model does not enable the tenant option or the caller has the root privileges,
+// throw a 403 exception if the tenant of the caller is not found.
+func (mod *Model) tenant() (id interface{}, scoped bool) {
+	tenant := mod.MetaData.Option.Tenant
+	if tenant == nil {
+		return nil, false
+	}
+
+	if root, _ := mod.global[process.RootKey].(bool); root {
+		return nil, false
+	}
+
+	if tenant.Global != "" {
+		if id, has := mod.global[tenant.Global]; has && id != nil {
+			return id, true
+		}
+	}
+
+	if mod.sid != "" {
+		key := tenant.Session
+		if key == "" {
+			key = TenantSessionKey
+		}
+
+		id, err := session.Global().ID(mod.sid).Get(key)
+		if err != nil {
+			log.Warn("[Model] %s session tenant: %s", mod.ID, err.Error())
+		}
+
+		if id != nil {
+			return id, true
+		}
+	}
+
+	exception.New("%s: the tenant of the caller is not found", 403, mod.ID).Throw()
+	return nil, true
+}
+
+// tenantWhere add the tenant condition to the query
+func (mod *Model) tenantWhere(qb query.Query) {
+	if id, scoped := mod.tenant(); scoped {
+		qb.Where(mod.tenantColumn(), id)
+	}
+}
+
+// setTenant stamp the tenant id on the created row
+func (mod *Model) setTenant(row maps.MapStrAny) {
+	if id, scoped := mod.tenant(); scoped {
+		row.Set(mod.tenantColumn(), id)
+	}
+}
+
+// unsetTenant remove the tenant column of the updated row, the tenant of the row can not be changed by the scoped caller
+func (mod *Model) unsetTenant(row maps.MapStrAny) {
+	if _, scoped := mod.tenant(); scoped {
+		row.Del(mod.tenantColumn())
+	}
+}
+
+// tenantValues stamp the tenant id on the inserted rows, return the columns with the tenant column
+func (mod *Model) tenantValues(columns []string, rows [][]interface{}) []string {
+	id, scoped := mod.tenant()
+	if !scoped {
+		return columns
+	}
+
+	column := mod.tenantColumn()
+	for i, name := range columns {
+		if name == column {
+			for r := range rows {
+				rows[r][i] = id
+			}
+			return columns
+		}
+	}
+
+	for r := range rows {
+		rows[r] = append(rows[r], id)
+	}
+	return append(columns, column)
+}
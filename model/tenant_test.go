@@ -0,0 +1,140 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/gou/store"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestTenantScope(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.tenant")
+	foo := mod.WithGlobal(map[string]interface{}{"tenant": 1})
+	bar := mod.WithGlobal(map[string]interface{}{"tenant": 2})
+
+	id := foo.MustCreate(maps.MapStrAny{"name": "foo-1", "tenant_id": 2})
+	foo.MustInsert([]string{"name"}, [][]interface{}{{"foo-2"}, {"foo-3"}})
+	bar.MustCreate(maps.MapStrAny{"name": "bar-1"})
+
+	assert.Len(t, foo.MustGet(QueryParam{}), 3)
+	assert.Len(t, bar.MustGet(QueryParam{}), 1)
+	assert.Equal(t, 1, any.Of(foo.MustFind(id, QueryParam{}).Get("tenant_id")).CInt())
+	assert.Panics(t, func() { bar.MustFind(id, QueryParam{}) })
+
+	// the rows of the other tenant can not be changed
+	assert.NotNil(t, bar.Update(id, maps.MapStrAny{"name": "changed"}))
+	assert.Equal(t, 0, bar.MustUpdateWhere(QueryParam{Wheres: []QueryWhere{{Column: "name", Value: "foo-2"}}}, maps.MapStrAny{"name": "changed"}))
+	assert.Equal(t, 1, bar.MustDeleteWhere(QueryParam{}))
+	assert.Equal(t, 1, bar.MustDestroyWhere(QueryParam{})) // the trashed row of bar
+	assert.Len(t, foo.MustGet(QueryParam{}), 3)
+
+	// the tenant of the row can not be changed
+	foo.MustUpdate(id, maps.MapStrAny{"name": "foo-0", "tenant_id": 2})
+	assert.Equal(t, 1, any.Of(foo.MustFind(id, QueryParam{}).Get("tenant_id")).CInt())
+
+	// the tenant is required
+	assert.Panics(t, func() { mod.WithGlobal(map[string]interface{}{}).MustGet(QueryParam{}) })
+
+	// root privileges
+	assert.Len(t, mod.WithRoot().MustGet(QueryParam{}), 3)
+}
+
+func TestTenantUpsert(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.tenant")
+	foo := mod.WithGlobal(map[string]interface{}{"tenant": 1})
+	bar := mod.WithGlobal(map[string]interface{}{"tenant": 2})
+	id := foo.MustCreate(maps.MapStrAny{"name": "foo-1"})
+
+	// the rows of the other tenant can not be overwritten
+	assert.Equal(t, 403, exceptionCode(func() {
+		bar.MustUpsert([]string{"id", "name"}, [][]interface{}{{id, "changed"}}, []string{"id"}, nil)
+	}))
+	assert.Equal(t, "foo-1", foo.MustFind(id, QueryParam{}).Get("name"))
+
+	// the rows of the caller are updated, the new rows are inserted with the tenant of the caller
+	foo.MustUpsert([]string{"id", "name"}, [][]interface{}{{id, "foo-0"}}, []string{"id"}, nil)
+	bar.MustUpsert([]string{"id", "name"}, [][]interface{}{{id + 100, "bar-1"}}, []string{"id"}, nil)
+	assert.Equal(t, "foo-0", foo.MustFind(id, QueryParam{}).Get("name"))
+	assert.Len(t, bar.MustGet(QueryParam{}), 1)
+}
+
+func TestTenantHistory(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	defer SetAuditor(nil)
+
+	lru, err := store.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetAuditor(StoreAuditor{Store: lru})
+
+	mod := prepareTests(t, "tests.tenant.logging")
+	foo := mod.WithGlobal(map[string]interface{}{"tenant": 1})
+	bar := mod.WithGlobal(map[string]interface{}{"tenant": 2})
+	id := foo.MustCreate(maps.MapStrAny{"name": "foo"})
+	foo.MustDelete(id)
+
+	// the trashed rows of the caller
+	history, err := foo.History(id)
+	assert.Nil(t, err)
+	assert.Len(t, history, 2)
+
+	// the rows of the other tenant
+	_, err = bar.History(id)
+	assert.True(t, errors.Is(err, errHistoryNotFound))
+	assert.Equal(t, 404, exceptionCode(func() {
+		process.New("models.tests.tenant.logging.History", id).WithGlobal(map[string]interface{}{"tenant": 2}).Run()
+	}))
+}
+
+func TestTenantJoin(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.tenant")
+	item := prepareTests(t, "tests.tenant.item")
+	foo := mod.WithGlobal(map[string]interface{}{"tenant": 1}).MustCreate(maps.MapStrAny{"name": "foo"})
+	bar := mod.WithGlobal(map[string]interface{}{"tenant": 2}).MustCreate(maps.MapStrAny{"name": "bar"})
+	item.MustInsert([]string{"name", "owner_id"}, [][]interface{}{{"item-foo", foo}, {"item-bar", bar}, {"item-none", nil}})
+
+	// the rows of the other tenant are not joined, the main rows are kept
+	rows := item.WithGlobal(map[string]interface{}{"tenant": 1}).MustGet(QueryParam{
+		Withs:  map[string]With{"owner": {}},
+		Orders: []QueryOrder{{Column: "id"}},
+	})
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "foo", maps.Of(rows[0]).Dot().Get("owner.name"))
+	assert.Nil(t, maps.Of(rows[1]).Dot().Get("owner.name"))
+	assert.Nil(t, maps.Of(rows[2]).Dot().Get("owner.name"))
+}
+
+func TestTenantProcess(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.tenant")
+	mod.WithGlobal(map[string]interface{}{"tenant": 1}).MustCreate(maps.MapStrAny{"name": "foo-1"})
+	mod.WithGlobal(map[string]interface{}{"tenant": 2}).MustCreate(maps.MapStrAny{"name": "bar-1"})
+
+	rows := process.New("models.tests.tenant.Get", map[string]interface{}{}).
+		WithGlobal(map[string]interface{}{"tenant": 2}).
+		Run().([]maps.MapStr)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "bar-1", rows[0].Get("name"))
+
+	rows = process.New("models.tests.tenant.Get", map[string]interface{}{}).
+		WithGlobal(map[string]interface{}{"tenant": 2}).
+		WithRoot(true).
+		Run().([]maps.MapStr)
+	assert.Len(t, rows, 2)
+
+	_, err := process.New("models.tests.tenant.Get", map[string]interface{}{}).Exec()
+	assert.NotNil(t, err)
+}
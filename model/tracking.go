@@ -0,0 +1,59 @@
+package model
+
+import (
+	"github.com/yaoapp/gou/session"
+	"github.com/yaoapp/kun/log"
+	"github.com/yaoapp/kun/maps"
+)
+
+// TrackingSessionKey the session key of the user id, it fills the created_by, updated_by and deleted_by columns
+var TrackingSessionKey = "user_id"
+
+// WithSID bind the session id of the caller, return a copy of the model
+func (mod *Model) WithSID(sid string) *Model {
+	if sid == "" {
+		return mod
+	}
+	new := *mod
+	new.sid = sid
+	return &new
+}
+
+// trackingUser get the user id of the session, return nil if the trackings option is off or the user is not found
+func (mod *Model) trackingUser() interface{} {
+	if !mod.MetaData.Option.Trackings {
+		return nil
+	}
+	return mod.sessionUser()
+}
+
+// sessionUser get the user id of the session
+func (mod *Model) sessionUser() interface{} {
+	if mod.sid == "" {
+		return nil
+	}
+
+	user, err := session.Global().ID(mod.sid).Get(TrackingSessionKey)
+	if err != nil {
+		log.Warn("[Model] %s session user: %s", mod.ID, err.Error())
+		return nil
+	}
+	return user
+}
+
+// setTracking fill the tracking column with the user id of the session
+func (mod *Model) setTracking(row maps.MapStrAny, column string) {
+	if user := mod.trackingUser(); user != nil {
+		row.Set(column, user)
+	}
+}
+
+// hasColumn check if the column is in the list
+func hasColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/gou/session"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/xun/capsule"
+)
+
+func TestTrackings(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+
+	mod := prepareTests(t, "tests.tracking")
+
+	for _, name := range []string{"created_by", "updated_by", "deleted_by"} {
+		assert.Contains(t, mod.ColumnNames, name)
+	}
+
+	sid := session.ID()
+	session.Global().ID(sid).Set(TrackingSessionKey, 10)
+
+	id := process.New("models.tests.tracking.Create", map[string]interface{}{"name": "foo"}).WithSID(sid).Run()
+	row := mod.MustFind(id, QueryParam{})
+	assert.Equal(t, 10, any.Of(row.Get("created_by")).CInt())
+	assert.Nil(t, row.Get("updated_by"))
+
+	session.Global().ID(sid).Set(TrackingSessionKey, 20)
+	process.New("models.tests.tracking.Save", map[string]interface{}{"id": id, "name": "bar", "created_by": 99}).WithSID(sid).Run()
+	row = mod.MustFind(id, QueryParam{})
+	assert.Equal(t, 10, any.Of(row.Get("created_by")).CInt())
+	assert.Equal(t, 20, any.Of(row.Get("updated_by")).CInt())
+
+	process.New("models.tests.tracking.Delete", id).WithSID(sid).Run()
+	rows := mod.MustGet(QueryParam{Wheres: []QueryWhere{{Column: "id", Value: id}}})
+	assert.Len(t, rows, 0)
+	deleted := capsule.Query().Table("tests_tracking").Where("id", id).MustFirst()
+	assert.Equal(t, 20, any.Of(deleted["deleted_by"]).CInt())
+
+	// Without session
+	id = mod.MustCreate(map[string]interface{}{"name": "anonymous"})
+	row = mod.MustFind(id, QueryParam{})
+	assert.Nil(t, row.Get("created_by"))
+}
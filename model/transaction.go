@@ -0,0 +1,116 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/xun/dbal/query"
+)
+
+// TransactionKey the name of the transaction id in the global vars, the processes called with it run in the transaction
+var TransactionKey = "__transaction"
+
+// Tx the database transaction, the models selected with it run in the transaction
+type Tx struct {
+	ID        string
+	Connector string
+	qb        query.Query
+}
+
+// transactions the running transactions
+var transactions = sync.Map{}
+
+// Transaction run the function in a transaction of the connector (the default connection if it is empty).
+// The transaction is rolled back if the function returns an error or throws an exception.
+func Transaction(connector string, fn func(tx *Tx) error) error {
+	manager, err := connectorManager(connector)
+	if err != nil {
+		return err
+	}
+
+	return manager.Query().Transaction(func(qb query.Query) (err error) {
+		tx := &Tx{ID: transactionID(), Connector: ConnectorName(connector), qb: qb}
+		transactions.Store(tx.ID, tx)
+		defer transactions.Delete(tx.ID)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = exception.Catch(recovered)
+			}
+		}()
+		return fn(tx)
+	})
+}
+
+// TransactionOf get the running transaction of the global vars, return nil if the caller is not in a transaction
+func TransactionOf(global map[string]interface{}) *Tx {
+	id, ok := global[TransactionKey].(string)
+	if !ok || id == "" {
+		return nil
+	}
+
+	tx, ok := transactions.Load(id)
+	if !ok {
+		return nil
+	}
+	return tx.(*Tx)
+}
+
+// Select select the model in the transaction
+func (tx *Tx) Select(id string) *Model {
+	mod := Select(id)
+	if ConnectorName(mod.MetaData.Connector) != tx.Connector {
+		exception.New("model %s is not on the connector %s of the transaction", 400, id, tx.Connector).Throw()
+	}
+	return mod.withTx(tx)
+}
+
+// Global the global vars to run the processes in the transaction
+func (tx *Tx) Global(global map[string]interface{}) map[string]interface{} {
+	new := map[string]interface{}{}
+	for name, value := range global {
+		new[name] = value
+	}
+	new[TransactionKey] = tx.ID
+	return new
+}
+
+// WithGlobal bind the global vars of the caller, the model runs in the transaction of the caller.
+// return a copy of the model
+func (mod *Model) WithGlobal(global map[string]interface{}) *Model {
+	if global == nil {
+		return mod
+	}
+
+	new := *mod
+	new.global = global
+	if tx := TransactionOf(global); tx != nil && ConnectorName(mod.MetaData.Connector) == tx.Connector {
+		new.tx = tx
+	}
+	return &new
+}
+
+// withTx bind the transaction, return the model itself if it is not on the connector of the transaction
+func (mod *Model) withTx(tx *Tx) *Model {
+	if tx == nil || mod.tx == tx || ConnectorName(mod.MetaData.Connector) != tx.Connector {
+		return mod
+	}
+
+	new := *mod
+	new.tx = tx
+	if new.global == nil {
+		new.global = map[string]interface{}{}
+	}
+	new.global = tx.Global(new.global)
+	return &new
+}
+
+func transactionID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(fmt.Sprintf("transaction id: %s", err.Error()))
+	}
+	return hex.EncodeToString(bytes)
+}
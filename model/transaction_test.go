@@ -0,0 +1,71 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestTransaction(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.tx.order")
+
+	// Commit
+	err := Transaction("", func(tx *Tx) error {
+		order := tx.Select("tests.tx.order")
+		id := order.MustCreate(maps.MapStrAny{"name": "foo"})
+		row := order.MustFind(id, QueryParam{}) // read in the transaction
+		assert.Equal(t, "foo", row.Get("name"))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, mod.MustGet(QueryParam{}), 1)
+
+	// Rollback with error
+	err = Transaction("", func(tx *Tx) error {
+		tx.Select("tests.tx.order").MustCreate(maps.MapStrAny{"name": "bar"})
+		return fmt.Errorf("abort")
+	})
+	assert.Equal(t, "abort", err.Error())
+	assert.Len(t, mod.MustGet(QueryParam{}), 1)
+
+	// Rollback with exception
+	err = Transaction("", func(tx *Tx) error {
+		tx.Select("tests.tx.order").MustCreate(maps.MapStrAny{"name": "bar"})
+		exception.New("abort", 400).Throw()
+		return nil
+	})
+	assert.NotNil(t, err)
+	assert.Len(t, mod.MustGet(QueryParam{}), 1)
+}
+
+func TestTransactionProcess(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.tx.order")
+
+	process.Register("tests.tx.abort", func(p *process.Process) interface{} {
+		exception.New("abort", 409).Throw()
+		return nil
+	})
+
+	res := process.New("models.Transaction", []interface{}{
+		map[string]interface{}{"process": "models.tests.tx.order.Create", "args": []interface{}{map[string]interface{}{"name": "foo"}}},
+		map[string]interface{}{"process": "models.tests.tx.order.Create", "args": []interface{}{map[string]interface{}{"name": "bar"}}},
+	}).Run()
+	assert.Len(t, res, 2)
+	assert.Len(t, mod.MustGet(QueryParam{}), 2)
+
+	assert.PanicsWithValue(t, *exception.New("abort", 409), func() {
+		process.New("models.tests.tx.order.Transaction", []interface{}{
+			map[string]interface{}{"process": "models.tests.tx.order.Create", "args": []interface{}{map[string]interface{}{"name": "baz"}}},
+			map[string]interface{}{"process": "tests.tx.abort"},
+		}).Run()
+	})
+	assert.Len(t, mod.MustGet(QueryParam{}), 2)
+}
@@ -26,50 +26,62 @@ type Model struct {
 	File          string
 	Driver        string // Driver
 	MetaData      MetaData
-	Columns       map[string]*Column // 字段映射表
-	ColumnNames   []interface{}      // 字段名称清单
-	PrimaryKey    string             // 主键(单一主键)
-	PrimaryKeys   []string           // 主键(联合主键)
-	UniqueColumns []*Column          // 唯一字段清单
+	Columns       map[string]*Column     // 字段映射表
+	ColumnNames   []interface{}          // 字段名称清单
+	PrimaryKey    string                 // 主键(单一主键)
+	PrimaryKeys   []string               // 主键(联合主键)
+	UniqueColumns []*Column              // 唯一字段清单
+	sid           string                 // the session id of the caller (WithSID)
+	global        map[string]interface{} // the global vars of the caller (WithGlobal)
+	tx            *Tx                    // the transaction (WithGlobal, Tx.Select)
 }
 
 // MetaData 元数据
 type MetaData struct {
-	Name      string              `json:"name,omitempty"`      // 元数据名称
-	Connector string              `json:"connector,omitempty"` // Bind a connector, MySQL, SQLite, Postgres, Clickhouse, Tidb, Oracle support. default is SQLite
-	Table     Table               `json:"table,omitempty"`     // 数据表选项
-	Columns   []Column            `json:"columns,omitempty"`   // 字段定义
-	Indexes   []Index             `json:"indexes,omitempty"`   // 索引定义
-	Relations map[string]Relation `json:"relations,omitempty"` // 映射关系定义
-	Values    []maps.MapStrAny    `json:"values,omitempty"`    // 初始数值
-	Option    Option              `json:"option,omitempty"`    // 元数据配置
+	Name        string                `json:"name,omitempty"`        // 元数据名称
+	Connector   string                `json:"connector,omitempty"`   // Bind a connector, MySQL, SQLite, Postgres, Clickhouse, Tidb, Oracle support. default is SQLite
+	Table       Table                 `json:"table,omitempty"`       // 数据表选项
+	Columns     []Column              `json:"columns,omitempty"`     // 字段定义
+	Indexes     []Index               `json:"indexes,omitempty"`     // 索引定义
+	Relations   map[string]Relation   `json:"relations,omitempty"`   // 映射关系定义
+	Values      []maps.MapStrAny      `json:"values,omitempty"`      // 初始数值
+	Option      Option                `json:"option,omitempty"`      // 元数据配置
+	Hooks       Hooks                 `json:"hooks,omitempty"`       // 生命周期回调处理器
+	Permissions map[string]Permission `json:"permissions,omitempty"` // 字段权限 role => columns, 需开启 permission 选项
 }
 
 // Column the field description struct
 type Column struct {
-	Label       string       `json:"label,omitempty"`
-	Name        string       `json:"name"`
-	Type        string       `json:"type,omitempty"`
-	Title       string       `json:"title,omitempty"`
-	Description string       `json:"description,omitempty"`
-	Comment     string       `json:"comment,omitempty"`
-	Length      int          `json:"length,omitempty"`
-	Precision   int          `json:"precision,omitempty"`
-	Scale       int          `json:"scale,omitempty"`
-	Nullable    bool         `json:"nullable,omitempty"`
-	Option      []string     `json:"option,omitempty"`
-	Default     interface{}  `json:"default,omitempty"`
-	DefaultRaw  string       `json:"default_raw,omitempty"`
-	Example     interface{}  `json:"example,omitempty"`
-	Generate    string       `json:"generate,omitempty"` // Increment, UUID,...
-	Crypt       string       `json:"crypt,omitempty"`    // AES, PASSWORD, AES-256, AES-128, PASSWORD-HASH, ...
-	Validations []Validation `json:"validations,omitempty"`
-	Index       bool         `json:"index,omitempty"`
-	Unique      bool         `json:"unique,omitempty"`
-	Primary     bool         `json:"primary,omitempty"`
+	Label       string         `json:"label,omitempty"`
+	Name        string         `json:"name"`
+	Type        string         `json:"type,omitempty"`
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Comment     string         `json:"comment,omitempty"`
+	Length      int            `json:"length,omitempty"`
+	Precision   int            `json:"precision,omitempty"`
+	Scale       int            `json:"scale,omitempty"`
+	Nullable    bool           `json:"nullable,omitempty"`
+	Option      []string       `json:"option,omitempty"`
+	Default     interface{}    `json:"default,omitempty"`
+	DefaultRaw  string         `json:"default_raw,omitempty"`
+	Example     interface{}    `json:"example,omitempty"`
+	Generate    string         `json:"generate,omitempty"` // Increment, UUID,...
+	Crypt       string         `json:"crypt,omitempty"`    // AES, PASSWORD, AES-256, AES-128, PASSWORD-HASH, ...
+	Validations []Validation   `json:"validations,omitempty"`
+	Index       bool           `json:"index,omitempty"`
+	Unique      bool           `json:"unique,omitempty"`
+	Primary     bool           `json:"primary,omitempty"`
+	Virtual     *VirtualColumn `json:"virtual,omitempty"` // 虚拟字段, 不创建数据表字段
 	model       *Model
 }
 
+// VirtualColumn the virtual column, the value is computed by the SQL expression or the process (after fetching)
+type VirtualColumn struct {
+	Expression string `json:"expression,omitempty"` // SQL expression, e.g. CONCAT(first_name, ' ', last_name)
+	Process    string `json:"process,omitempty"`    // the process name, args: (row), return the value of the column
+}
+
 // Validation the field validation struct
 type Validation struct {
 	Method  string        `json:"method"`
@@ -112,17 +124,60 @@ type Relation struct {
 	Foreign string     `json:"foreign,omitempty"`
 	Links   []Relation `json:"links,omitempty"`
 	Query   QueryParam `json:"query,omitempty"`
+	Pivot   *Pivot     `json:"pivot,omitempty"` // belongsToMany, morphToMany, morphByMany
+	Morph   *Morph     `json:"morph,omitempty"` // morphOne, morphMany, morphToMany, morphByMany
+}
+
+// Pivot the pivot table of the many-to-many relations
+type Pivot struct {
+	Table   string   `json:"table"`             // the pivot table name, eg: user_role
+	Key     string   `json:"key"`               // the column refers to the related model, eg: role_id
+	Foreign string   `json:"foreign"`           // the column refers to the current model, eg: user_id
+	Columns []string `json:"columns,omitempty"` // the pivot columns, exported as pivot.<column>
+}
+
+// Morph the polymorphic setting of the morph relations
+type Morph struct {
+	Column string `json:"column"`          // the type column, eg: commentable_type
+	Value  string `json:"value,omitempty"` // the type value, default is the model id (the related model id for morphByMany)
 }
 
 // Option 模型配置选项
 type Option struct {
-	Timestamps  bool `json:"timestamps,omitempty"`   // + created_at, updated_at 字段
-	SoftDeletes bool `json:"soft_deletes,omitempty"` // + deleted_at 字段
-	Trackings   bool `json:"trackings,omitempty"`    // + created_by, updated_by, deleted_by 字段
-	Constraints bool `json:"constraints,omitempty"`  // + 约束定义
-	Permission  bool `json:"permission,omitempty"`   // + __permission 字段
-	Logging     bool `json:"logging,omitempty"`      // + __logging_id 字段
-	Readonly    bool `json:"read_only,omitempty"`    // Ignore the migrate operation
+	Timestamps  bool    `json:"timestamps,omitempty"`   // + created_at, updated_at 字段
+	SoftDeletes bool    `json:"soft_deletes,omitempty"` // + deleted_at 字段
+	Trackings   bool    `json:"trackings,omitempty"`    // + created_by, updated_by, deleted_by 字段
+	Constraints bool    `json:"constraints,omitempty"`  // + 约束定义
+	Permission  bool    `json:"permission,omitempty"`   // 按角色控制字段读写权限 (permissions)
+	Logging     bool    `json:"logging,omitempty"`      // 记录数据变更日志 (审计), 由 SetAuditor 设置的写入器写入
+	Readonly    bool    `json:"read_only,omitempty"`    // Ignore the migrate operation
+	Version     bool    `json:"version,omitempty"`      // + __version 字段 (乐观锁)
+	Tenant      *Tenant `json:"tenant,omitempty"`       // + 租户字段, 按租户过滤数据
+}
+
+// Tenant the tenant scoping of the model, the tenant id is resolved from the global vars or the session
+type Tenant struct {
+	Column  string `json:"column,omitempty"`  // the tenant column (default tenant_id)
+	Session string `json:"session,omitempty"` // the session key of the tenant id (default tenant_id)
+	Global  string `json:"global,omitempty"`  // the global var name of the tenant id, it is used before the session
+}
+
+// Permission the readable and writable columns of a role, "*" means all the columns.
+// the permissions of the role "*" are granted to all the callers.
+type Permission struct {
+	Read  []string `json:"read,omitempty"`
+	Write []string `json:"write,omitempty"`
+}
+
+// Hooks the lifecycle hooks of the model, the values are the process names
+type Hooks struct {
+	BeforeCreate string `json:"beforeCreate,omitempty"` // (row) return the new row
+	AfterCreate  string `json:"afterCreate,omitempty"`  // (id, row)
+	BeforeSave   string `json:"beforeSave,omitempty"`   // (row, id?) return the new row
+	AfterSave    string `json:"afterSave,omitempty"`    // (id, row)
+	BeforeDelete string `json:"beforeDelete,omitempty"` // (id)
+	AfterDelete  string `json:"afterDelete,omitempty"`  // (id)
+	AfterFind    string `json:"afterFind,omitempty"`    // (row) return the new row
 }
 
 // ColumnMap ColumnMap 字段映射
@@ -141,17 +196,25 @@ type ExportData struct {
 
 // QueryParam 数据查询器参数
 type QueryParam struct {
-	Model    string          `json:"model,omitempty"`
-	Table    string          `json:"table,omitempty"`
-	Alias    string          `json:"alias,omitempty"`
-	Export   string          `json:"export,omitempty"` // 导出前缀
-	Select   []interface{}   `json:"select,omitempty"` // string | dbal.Raw
-	Wheres   []QueryWhere    `json:"wheres,omitempty"`
-	Orders   []QueryOrder    `json:"orders,omitempty"`
-	Limit    int             `json:"limit,omitempty"`
-	Page     int             `json:"page,omitempty"`
-	PageSize int             `json:"pagesize,omitempty"`
-	Withs    map[string]With `json:"withs,omitempty"`
+	Model       string                 `json:"model,omitempty"`
+	Table       string                 `json:"table,omitempty"`
+	Alias       string                 `json:"alias,omitempty"`
+	Export      string                 `json:"export,omitempty"` // 导出前缀
+	Select      []interface{}          `json:"select,omitempty"` // string | dbal.Raw
+	Wheres      []QueryWhere           `json:"wheres,omitempty"`
+	Orders      []QueryOrder           `json:"orders,omitempty"`
+	Limit       int                    `json:"limit,omitempty"`
+	Page        int                    `json:"page,omitempty"`
+	PageSize    int                    `json:"pagesize,omitempty"`
+	Withs       map[string]With        `json:"withs,omitempty"`
+	Groups      []string               `json:"groups,omitempty"`      // 分组字段
+	Havings     []QueryHaving          `json:"havings,omitempty"`     // 分组筛选条件
+	Aggregates  []QueryAggregate       `json:"aggregates,omitempty"`  // 统计字段 count/sum/avg/min/max
+	WithTrashed bool                   `json:"withTrashed,omitempty"` // 包含已删除数据 (软删除)
+	OnlyTrashed bool                   `json:"onlyTrashed,omitempty"` // 仅查询已删除数据 (软删除)
+	tx          *Tx                    // the transaction of the model
+	sid         string                 // the session id of the caller
+	global      map[string]interface{} // the global vars of the caller
 }
 
 // With relations 关联查询
@@ -168,6 +231,24 @@ type QueryWhere struct {
 	Method string       `json:"method,omitempty"` // where,orwhere, wherein, orwherein...
 	OP     string       `json:"op,omitempty"`     // 操作 eq/gt/lt/ge/le/like...
 	Wheres []QueryWhere `json:"wheres,omitempty"` // 分组查询
+
+	internal bool // 模型内部条件 (软删除, 租户, 版本, 关联定义等), 不检查字段读权限
+}
+
+// QueryAggregate 统计字段
+type QueryAggregate struct {
+	Func     string `json:"func"`               // count, sum, avg, min, max
+	Column   string `json:"column,omitempty"`   // 统计字段, count 可为空或 *
+	Alias    string `json:"alias,omitempty"`    // 导出名称, 默认为 <func>_<column>
+	Distinct bool   `json:"distinct,omitempty"` // 去重统计
+}
+
+// QueryHaving Having 分组筛选条件
+type QueryHaving struct {
+	Column string      `json:"column"` // 统计字段导出名称或分组字段
+	Value  interface{} `json:"value,omitempty"`
+	Method string      `json:"method,omitempty"` // having, orhaving
+	OP     string      `json:"op,omitempty"`     // 操作 eq/ne/gt/lt/ge/le
 }
 
 // QueryOrder Order 查询排序
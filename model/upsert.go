@@ -0,0 +1,315 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/log"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/dbal"
+	"github.com/yaoapp/xun/dbal/query"
+)
+
+// UpsertChunkSize the number of rows of each upsert query
+var UpsertChunkSize = 500
+
+// Upsert 批量插入或更新数据 (MySQL: ON DUPLICATE KEY UPDATE, Postgres/SQLite: ON CONFLICT DO UPDATE)
+// uniqueBy 为唯一字段(或联合唯一索引字段), updateColumns 为冲突时更新的字段 (默认除 uniqueBy 外的全部字段), 返回影响行数
+// 与 Insert 相同, 批量写入不触发 Hooks; 开启 logging 时逐批次读取写入前后的数据, 记录插入及更新日志
+func (mod *Model) Upsert(columns []string, rows [][]interface{}, uniqueBy []string, updateColumns []string) (int, error) {
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	// the version could not be checked and incremented by the upsert query
+	if mod.MetaData.Option.Version {
+		return 0, fmt.Errorf("the model %s enables the version option, upsert is not supported, use Update or Save with the version", mod.ID)
+	}
+
+	// the virtual columns are not stored
+	columns, rows = mod.virtualDrop(columns, rows)
+
+	if !mod.isUniqueBy(uniqueBy) {
+		return 0, fmt.Errorf("the columns %s should be the primary key or an unique index", strings.Join(uniqueBy, ","))
+	}
+
+	for _, name := range append(append([]string{}, uniqueBy...), updateColumns...) {
+		if !hasColumn(columns, name) {
+			return 0, fmt.Errorf("the column %s is not in the columns", name)
+		}
+	}
+
+	if len(updateColumns) == 0 {
+		for _, name := range columns {
+			if !hasColumn(uniqueBy, name) && name != mod.PrimaryKey && name != "created_at" && name != "created_by" && name != VersionColumn {
+				updateColumns = append(updateColumns, name)
+			}
+		}
+	}
+
+	// 数据校验 & 入库前输入数据预处理
+	errs := []ValidateResponse{}
+	values := [][]interface{}{}
+	snapshots := []maps.MapStrAny{}
+	for rid, row := range rows {
+		if len(row) != len(columns) {
+			errs = append(errs, ValidateResponse{
+				Line:     rid,
+				Column:   "*",
+				Messages: []string{fmt.Sprintf("第%d条数据，字段数量与提供字段清单不符.", rid+1)},
+			})
+			continue
+		}
+
+		input := maps.MakeMapStr()
+		for cid, name := range columns {
+			input[name] = row[cid]
+		}
+
+		for _, err := range mod.validate(validationContext{mod: mod, row: input, bulk: true}) {
+			err.Line = rid
+			errs = append(errs, err)
+		}
+
+		if snapshot := mod.auditSnapshot(input); snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
+
+		mod.FliterIn(input)
+		value := []interface{}{}
+		for _, name := range columns {
+			value = append(value, input[name])
+		}
+		values = append(values, value)
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			log.Error("[Model] %s Upsert %v", mod.ID, err)
+		}
+		exception.New("输入参数错误", 400).Ctx(errs).Throw()
+	}
+
+	columns = mod.tenantValues(append([]string{}, columns...), values)
+	updates := []string{}
+	for _, name := range updateColumns {
+		if name != mod.tenantColumn() {
+			updates = append(updates, name)
+		}
+	}
+
+	// 添加时间戳, 更新时仅修改 updated_at
+	if mod.MetaData.Option.Timestamps {
+		columns = append(columns, "created_at", "updated_at")
+		updates = append(updates, "updated_at")
+		for i := range values {
+			values[i] = append(values[i], dbal.Raw("CURRENT_TIMESTAMP"), dbal.Raw("CURRENT_TIMESTAMP"))
+		}
+	}
+
+	// 添加创建人/更新人
+	if user := mod.trackingUser(); user != nil {
+		for _, name := range []string{"created_by", "updated_by"} {
+			if hasColumn(columns, name) {
+				continue
+			}
+			columns = append(columns, name)
+			for i := range values {
+				values[i] = append(values[i], user)
+			}
+		}
+		if !hasColumn(updates, "updated_by") {
+			updates = append(updates, "updated_by")
+		}
+	}
+
+	// 单批次且无需记录日志时直接写入
+	if (len(values) <= UpsertChunkSize && len(snapshots) == 0) || mod.tx != nil {
+		return mod.upsertChunks(values, columns, uniqueBy, updates, snapshots)
+	}
+
+	// 多批次写入及日志读取在同一事务中完成
+	effect := 0
+	err := Transaction(mod.MetaData.Connector, func(tx *Tx) (err error) {
+		effect, err = mod.withTx(tx).upsertChunks(values, columns, uniqueBy, updates, snapshots)
+		return err
+	})
+	return effect, err
+}
+
+// MustUpsert 批量插入或更新数据, 失败抛出异常
+func (mod *Model) MustUpsert(columns []string, rows [][]interface{}, uniqueBy []string, updateColumns []string) int {
+	effect, err := mod.Upsert(columns, rows, uniqueBy, updateColumns)
+	if err != nil {
+		exception.Err(err, 500).Throw()
+	}
+	return effect
+}
+
+// upsertChunks write the rows chunk by chunk, the audit logs are written if the snapshots of the rows are given
+func (mod *Model) upsertChunks(values [][]interface{}, columns []string, uniqueBy []string, updates []string, snapshots []maps.MapStrAny) (int, error) {
+	size := UpsertChunkSize
+	if size <= 0 {
+		size = len(values)
+	}
+
+	effect := 0
+	for start := 0; start < len(values); start = start + size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
+
+		mod.upsertTenant(values[start:end], columns, uniqueBy)
+
+		var olds []maps.MapStr
+		if len(snapshots) > 0 {
+			olds = mod.upsertFind(values[start:end], columns, uniqueBy)
+		}
+
+		n, err := mod.newQuery().
+			Table(mod.MetaData.Table.Name).
+			Upsert(values[start:end], uniqueBy, updates, columns)
+		if err != nil {
+			return effect, err
+		}
+		effect = effect + int(n)
+
+		if len(snapshots) > 0 {
+			mod.upsertAudit(values[start:end], columns, uniqueBy, updates, snapshots[start:end], olds)
+		}
+	}
+	return effect, nil
+}
+
+// upsertTenant throw a 403 exception if the upserted rows conflict with the rows of the other tenants,
+// the conflicted rows are updated without the tenant condition unless the unique columns include the tenant column.
+func (mod *Model) upsertTenant(values [][]interface{}, columns []string, uniqueBy []string) {
+	id, scoped := mod.tenant()
+	column := mod.tenantColumn()
+	if !scoped || hasColumn(uniqueBy, column) {
+		return
+	}
+
+	qb := mod.newQuery().Table(mod.MetaData.Table.Name)
+	qb.Where(func(qb query.Query) {
+		for _, value := range values {
+			qb.OrWhere(func(sub query.Query) {
+				for _, name := range uniqueBy {
+					sub.Where(name, value[upsertIndex(columns, name)])
+				}
+			})
+		}
+	})
+	qb.Where(func(qb query.Query) {
+		qb.Where(column, "<>", id).OrWhereNull(column)
+	})
+
+	cnt, err := qb.Count()
+	if err != nil {
+		exception.Err(err, 500).Throw()
+	}
+	if cnt > 0 {
+		exception.New("%s: the upserted rows conflict with the rows of the other tenants", 403, mod.ID).Throw()
+	}
+}
+
+// upsertFind read the rows which have the same unique values with the upserted rows (the trashed rows are included)
+func (mod *Model) upsertFind(values [][]interface{}, columns []string, uniqueBy []string) []maps.MapStr {
+	index := upsertIndex(columns, uniqueBy[0])
+	keys := []interface{}{}
+	for _, value := range values {
+		keys = append(keys, value[index])
+	}
+	return mod.auditFind(QueryParam{
+		Wheres:      []QueryWhere{{Column: uniqueBy[0], OP: "in", Value: keys, internal: true}},
+		WithTrashed: true,
+	})
+}
+
+// upsertAudit write the insert logs of the new rows and the update logs of the conflicted rows
+func (mod *Model) upsertAudit(values [][]interface{}, columns []string, uniqueBy []string, updates []string, snapshots []maps.MapStrAny, olds []maps.MapStr) {
+	existing := map[string]maps.MapStr{}
+	for _, row := range olds {
+		existing[upsertKey(uniqueBy, func(name string) interface{} { return row.Get(name) })] = row
+	}
+
+	rows := map[string]maps.MapStr{}
+	for _, row := range mod.upsertFind(values, columns, uniqueBy) {
+		rows[upsertKey(uniqueBy, func(name string) interface{} { return row.Get(name) })] = row
+	}
+
+	for i, value := range values {
+		key := upsertKey(uniqueBy, func(name string) interface{} { return value[upsertIndex(columns, name)] })
+		row, has := rows[key]
+		if !has {
+			continue
+		}
+
+		old, has := existing[key]
+		if !has {
+			mod.audit(AuditInsert, row.Get(mod.PrimaryKey), nil, snapshots[i])
+			continue
+		}
+
+		changes := maps.MapStrAny{}
+		for _, name := range updates {
+			if snapshots[i].Has(name) {
+				changes[name] = snapshots[i][name]
+			}
+		}
+		mod.audit(AuditUpdate, row.Get(mod.PrimaryKey), old, changes)
+	}
+}
+
+// upsertKey the key of the unique values
+func upsertKey(uniqueBy []string, get func(name string) interface{}) string {
+	values := []string{}
+	for _, name := range uniqueBy {
+		values = append(values, fmt.Sprintf("%v", get(name)))
+	}
+	return strings.Join(values, "\x00")
+}
+
+// upsertIndex the index of the column
+func upsertIndex(columns []string, name string) int {
+	for i, column := range columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// isUniqueBy check if the columns are the primary key, an unique column or the columns of an unique index
+func (mod *Model) isUniqueBy(columns []string) bool {
+	if len(columns) == 0 {
+		return false
+	}
+
+	if len(columns) == 1 {
+		return mod.isUnique(columns[0])
+	}
+
+	for _, index := range mod.MetaData.Indexes {
+		typ := strings.ToLower(index.Type)
+		if (typ != "unique" && typ != "primary") || len(index.Columns) != len(columns) {
+			continue
+		}
+
+		matched := true
+		for _, name := range index.Columns {
+			if !hasColumn(columns, name) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+	return false
+}
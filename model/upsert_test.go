@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/gou/store"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestUpsert(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.upsert", 3)
+
+	created := mod.MustFind(1, QueryParam{}).Get("created_at")
+	_, err := mod.Upsert(
+		[]string{"email", "name", "secret"},
+		[][]interface{}{
+			{"user-0@test.com", "Updated", "new-password"},
+			{"new@test.com", "New", "password"},
+		},
+		[]string{"email"}, nil,
+	)
+	assert.Nil(t, err)
+
+	rows := mod.MustGet(QueryParam{Wheres: []QueryWhere{{Column: "email", Value: "user-0@test.com"}}})
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "Updated", rows[0].Get("name"))
+	assert.Equal(t, created, rows[0].Get("created_at"))
+	assert.NotNil(t, rows[0].Get("updated_at"))
+	assert.NotEqual(t, "new-password", rows[0].Get("secret"))
+	assert.Len(t, mod.MustGet(QueryParam{}), 4)
+
+	// update the given columns only
+	_, err = mod.Upsert(
+		[]string{"email", "name", "score"},
+		[][]interface{}{{"user-1@test.com", "Ignored", 99}},
+		[]string{"email"}, []string{"score"},
+	)
+	assert.Nil(t, err)
+	row := mod.MustFind(2, QueryParam{})
+	assert.Equal(t, "user-1", row.Get("name"))
+	assert.Equal(t, 99, any.Of(row.Get("score")).CInt())
+
+	// uniqueBy should be unique
+	_, err = mod.Upsert([]string{"email", "name"}, [][]interface{}{{"user-1@test.com", "user-1"}}, []string{"name"}, nil)
+	assert.NotNil(t, err)
+
+	// validate the rows
+	assert.Panics(t, func() {
+		mod.MustUpsert([]string{"email", "name"}, [][]interface{}{{"bad-email", "bad"}}, []string{"email"}, nil)
+	})
+}
+
+func TestUpsertChunk(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := seedTests(t, "tests.upsert", 3)
+
+	size := UpsertChunkSize
+	UpsertChunkSize = 2
+	defer func() { UpsertChunkSize = size }()
+
+	rows := []interface{}{}
+	for i := 0; i < 7; i++ {
+		rows = append(rows, []interface{}{fmt.Sprintf("user-%d@test.com", i), fmt.Sprintf("chunk-%d", i), i})
+	}
+
+	process.New("models.tests.upsert.Upsert", []interface{}{"email", "name", "score"}, rows, "email").Run()
+	assert.Len(t, mod.MustGet(QueryParam{}), 7)
+	assert.Len(t, mod.MustGet(QueryParam{Wheres: []QueryWhere{{Column: "name", OP: "match", Value: "chunk-"}}}), 7)
+}
+
+func TestUpsertAudit(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	defer SetAuditor(nil)
+
+	lru, err := store.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetAuditor(StoreAuditor{Store: lru})
+	mod := prepareTests(t, "tests.upsert.logging")
+	id := mod.MustCreate(maps.MapStrAny{"email": "foo@test.com", "name": "foo"})
+
+	mod.MustUpsert([]string{"email", "name"}, [][]interface{}{{"foo@test.com", "bar"}, {"new@test.com", "new"}}, []string{"email"}, nil)
+
+	history, err := mod.History(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, history, 2)
+	assert.Equal(t, AuditUpdate, history[1].Action)
+	assert.Equal(t, "foo", history[1].Changes["name"].Old)
+	assert.Equal(t, "bar", history[1].Changes["name"].New)
+
+	row := mod.MustGet(QueryParam{Wheres: []QueryWhere{{Column: "email", Value: "new@test.com"}}})[0]
+	history, err = mod.History(row.Get("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, history, 1)
+	assert.Equal(t, AuditInsert, history[0].Action)
+	assert.Equal(t, "new", history[0].Changes["name"].New)
+}
@@ -61,6 +61,9 @@ func URLToQueryParam(values url.Values) QueryParam {
 		} else if strings.HasSuffix(name, ".select") {
 			param.setWithSelect(name, values.Get(name))
 			continue
+		} else if name == "withTrashed" || name == "onlyTrashed" {
+			param.setTrashed(name, values.Get(name))
+			continue
 		}
 	}
 
@@ -84,6 +87,16 @@ func getURLValue(values url.Values, name string) interface{} {
 	return ""
 }
 
+// "withTrashed", "true" -> WithTrashed = true,  "onlyTrashed", "1" -> OnlyTrashed = true
+func (param *QueryParam) setTrashed(name string, value string) {
+	trashed := value != "false" && value != "0"
+	if name == "onlyTrashed" {
+		param.OnlyTrashed = trashed
+		return
+	}
+	param.WithTrashed = trashed
+}
+
 // "select", "name,secret,status,type" -> []interface{"name","secret"...}
 func (param *QueryParam) setSelect(value string) {
 	selects := []interface{}{}
@@ -28,3 +28,18 @@ func TestQueryUrlValues(t *testing.T) {
 	assert.Equal(t, len(param.Withs), 2)
 	assert.Equal(t, len(param.Orders), 2)
 }
+
+func TestQueryUrlTrashed(t *testing.T) {
+	params := url.Values{}
+	params.Add("withTrashed", "true")
+	param := URLToQueryParam(params)
+	assert.True(t, param.WithTrashed)
+	assert.False(t, param.OnlyTrashed)
+
+	params = url.Values{}
+	params.Add("onlyTrashed", "1")
+	params.Add("withTrashed", "false")
+	param = URLToQueryParam(params)
+	assert.False(t, param.WithTrashed)
+	assert.True(t, param.OnlyTrashed)
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/yaoapp/gou/lang"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/kun/str"
+)
+
+// validationContext the context of the model validators
+type validationContext struct {
+	mod    *Model
+	column *Column
+	row    maps.MapStrAny
+	id     interface{} // the primary key of the updated row, it is excluded by the unique validator
+	bulk   bool        // the conflicted rows of the upsert or the matched rows of UpdateWhere are updated, the unique validator is skipped (the unique index of the table is relied on)
+}
+
+// modelValidations the validators query the database or run the processes with the context of the model
+var modelValidations = map[string]func(ctx validationContext, value interface{}, args ...interface{}) bool{
+	"unique":  validationUnique,  // 唯一值 args: 组合唯一的其他字段
+	"exists":  validationExists,  // 关联数据存在 args: [模型, 字段 (默认主键)]
+	"process": validationProcess, // 处理器校验 args: [处理器, 参数...], 处理器参数 (value, row, 参数...)
+}
+
+// validate check the row, the messages are translated by the language dictionary
+func (mod *Model) validate(ctx validationContext) []ValidateResponse {
+	res := []ValidateResponse{}
+	for name, value := range ctx.row {
+		column, has := mod.Columns[name]
+		if !has {
+			continue
+		}
+
+		// 如果允许为 null
+		if value == nil && column.Nullable {
+			continue
+		}
+
+		ctx.column = column
+		success, messages := column.validate(ctx, value)
+		if !success {
+			res = append(res, ValidateResponse{
+				Column:   column.Name,
+				Messages: messages,
+			})
+		}
+	}
+	return res
+}
+
+// validate check the value with the validators of the column
+func (column *Column) validate(ctx validationContext, value interface{}) (bool, []string) {
+	messages := []string{}
+	success := true
+	for _, v := range column.Validations {
+		ok := true
+		if method, has := validationOf(v.Method); has {
+			ok = method(value, ctx.row, v.Args...)
+		} else if method, has := modelValidations[v.Method]; has && ctx.mod != nil {
+			ok = method(ctx, value, v.Args...)
+		}
+
+		if !ok {
+			data := column.Map()
+			data["input"] = value
+			message := str.Bind(ctx.mod.translate(v.Message), data)
+			messages = append(messages, message)
+			success = false
+		}
+	}
+	return success, messages
+}
+
+// translate translate the message by the default language dictionary, "::message" or "$L(message)"
+func (mod *Model) translate(message string) string {
+	if lang.Default == nil {
+		return message
+	}
+
+	widgets := []string{}
+	if mod != nil {
+		widgets = append(widgets, fmt.Sprintf("model.%s", mod.ID))
+	}
+
+	if !lang.Default.Replace(widgets, &message) {
+		lang.Default.ReplaceMatch(widgets, &message)
+	}
+	return message
+}
+
+// validationUnique the value should not be used by the other rows
+func validationUnique(ctx validationContext, value interface{}, args ...interface{}) bool {
+	if ctx.bulk {
+		return true
+	}
+
+	// the value is unique in the table, the rows of the other tenants and the deleted rows are included (no hooks are run)
+	mod := ctx.mod
+	qb := mod.newQuery().Table(mod.MetaData.Table.Name).Where(ctx.column.Name, value)
+	siblings := validationSiblings(ctx, args)
+	for _, arg := range args {
+		name := fmt.Sprintf("%v", arg)
+		if other := siblings.Get(name); other != nil {
+			qb.Where(name, other)
+			continue
+		}
+		qb.WhereNull(name)
+	}
+
+	if ctx.id != nil {
+		qb.Where(mod.PrimaryKey, "<>", ctx.id)
+	}
+
+	cnt, err := qb.Count()
+	if err != nil {
+		exception.Err(err, 500).Throw()
+	}
+	return cnt == 0
+}
+
+// validationSiblings the values of the other columns of the composite unique rule,
+// the columns which are not given by the partial update are read from the stored row.
+func validationSiblings(ctx validationContext, args []interface{}) maps.MapStrAny {
+	values := maps.MapStrAny{}
+	missing := []interface{}{}
+	for _, arg := range args {
+		name := fmt.Sprintf("%v", arg)
+		if value, has := ctx.row[name]; has {
+			values[name] = value
+			continue
+		}
+		missing = append(missing, name)
+	}
+
+	if len(missing) == 0 || ctx.id == nil {
+		return values
+	}
+
+	row, err := ctx.mod.WithRoot().find(ctx.id, QueryParam{Select: missing, WithTrashed: true})
+	if err != nil {
+		exception.Err(err, 500).Throw()
+	}
+	for _, name := range missing {
+		values[name.(string)] = row.Get(name.(string))
+	}
+	return values
+}
+
+// validationExists the row of the given model should exist
+func validationExists(ctx validationContext, value interface{}, args ...interface{}) bool {
+	if len(args) < 1 {
+		return false
+	}
+
+	mod := Select(fmt.Sprintf("%v", args[0])).WithSID(ctx.mod.sid).WithGlobal(ctx.mod.global)
+	column := mod.PrimaryKey
+	if len(args) > 1 {
+		column = fmt.Sprintf("%v", args[1])
+	}
+
+	// the row should be visible to the caller (the tenant and the soft deletes), no hooks are run
+	qb := mod.newQuery().Table(mod.MetaData.Table.Name).Where(column, value)
+	if mod.MetaData.Option.SoftDeletes {
+		qb.WhereNull("deleted_at")
+	}
+	mod.tenantWhere(qb)
+
+	cnt, err := qb.Count()
+	if err != nil {
+		exception.Err(err, 500).Throw()
+	}
+	return cnt > 0
+}
+
+// validationProcess run the process with the value and the row, the result is cast to bool
+func validationProcess(ctx validationContext, value interface{}, args ...interface{}) bool {
+	if len(args) < 1 {
+		return false
+	}
+
+	p := process.New(fmt.Sprintf("%v", args[0]), append([]interface{}{value, ctx.row}, args[1:]...)...)
+	return any.Of(ctx.mod.hook(p).Run()).CBool()
+}
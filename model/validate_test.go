@@ -0,0 +1,143 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/lang"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestValidateModelRules(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	process.Register("tests.validate.code", func(p *process.Process) interface{} {
+		return p.ArgsString(0) == "ok"
+	})
+	mod := prepareTests(t, "tests.validate")
+	id := mod.MustCreate(maps.MapStrAny{"email": "foo@test.com", "code": "ok"})
+
+	// unique
+	errs := mod.Validate(maps.MapStrAny{"email": "foo@test.com"})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "email", errs[0].Column)
+	assert.Len(t, mod.Validate(maps.MapStrAny{"email": "foo@test.com"}, id), 0)
+	mod.MustUpdate(id, maps.MapStrAny{"email": "foo@test.com"})
+	assert.Panics(t, func() { mod.MustCreate(maps.MapStrAny{"email": "foo@test.com"}) })
+
+	// exists
+	assert.Len(t, mod.Validate(maps.MapStrAny{"parent_id": 99}), 1)
+	assert.Len(t, mod.Validate(maps.MapStrAny{"parent_id": id}), 0)
+
+	// cross-field
+	assert.Len(t, mod.Validate(maps.MapStrAny{"start_date": "2022-01-02", "end_date": "2022-01-01"}), 1)
+	assert.Len(t, mod.Validate(maps.MapStrAny{"start_date": "2022-01-01", "end_date": "2022-01-02"}), 0)
+
+	// process
+	assert.Len(t, mod.Validate(maps.MapStrAny{"code": "bad"}), 1)
+	assert.Len(t, mod.Validate(maps.MapStrAny{"code": "ok"}), 0)
+
+	// upsert updates the conflicted rows
+	assert.NotPanics(t, func() {
+		mod.MustUpsert([]string{"email", "code"}, [][]interface{}{{"foo@test.com", "ok"}}, []string{"email"}, nil)
+	})
+
+	// the matched rows of UpdateWhere are not checked by the unique validator
+	assert.NotPanics(t, func() {
+		mod.MustUpdateWhere(QueryParam{Wheres: []QueryWhere{{Column: "id", Value: id}}}, maps.MapStrAny{"email": "foo@test.com"})
+	})
+}
+
+func TestValidateUniqueScope(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.validate.tenant")
+	foo := mod.WithGlobal(map[string]interface{}{"tenant": 1})
+	bar := mod.WithGlobal(map[string]interface{}{"tenant": 2})
+	id := foo.MustCreate(maps.MapStrAny{"code": "a", "slug": "a"})
+
+	// the rows of the other tenants are checked
+	assert.Len(t, bar.Validate(maps.MapStrAny{"code": "a"}), 1)
+	assert.Len(t, bar.Validate(maps.MapStrAny{"code": "b"}), 0)
+
+	// the deleted rows are checked
+	foo.MustDelete(id)
+	assert.Len(t, foo.Validate(maps.MapStrAny{"slug": "a"}), 1)
+}
+
+func TestValidateUniqueComposite(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	found := 0
+	process.Register("tests.validate.composite.afterFind", func(p *process.Process) interface{} {
+		found++
+		return p.Args[0]
+	})
+
+	mod := prepareTests(t, "tests.validate.composite")
+	mod.MustCreate(maps.MapStrAny{"name": "a", "category": 1})
+	id := mod.MustCreate(maps.MapStrAny{"name": "b", "category": 1})
+	other := mod.MustCreate(maps.MapStrAny{"name": "a", "category": 2})
+	found = 0
+
+	// the category of the partial update is read from the stored row
+	assert.Len(t, mod.Validate(maps.MapStrAny{"name": "a"}, id), 1)
+	assert.Len(t, mod.Validate(maps.MapStrAny{"name": "c"}, id), 0)
+	assert.Len(t, mod.Validate(maps.MapStrAny{"name": "a", "category": 3}, id), 0)
+	assert.Panics(t, func() { mod.MustUpdate(id, maps.MapStrAny{"name": "a"}) })
+	assert.Len(t, mod.Validate(maps.MapStrAny{"name": "a"}, other), 0)
+
+	// the missing category of the new row is null
+	assert.Len(t, mod.Validate(maps.MapStrAny{"name": "a"}), 0)
+
+	// the validators do not run the afterFind hook
+	assert.Len(t, mod.Validate(maps.MapStrAny{"parent_id": id}), 0)
+	assert.Len(t, mod.Validate(maps.MapStrAny{"parent_id": 99}), 1)
+	assert.Equal(t, 0, found)
+}
+
+func TestValidateTranslate(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	process.Register("tests.validate.code", func(p *process.Process) interface{} {
+		return p.ArgsString(0) == "ok"
+	})
+	mod := prepareTests(t, "tests.validate")
+	mod.MustCreate(maps.MapStrAny{"email": "foo@test.com"})
+
+	dict := lang.Default
+	defer func() { lang.Default = dict }()
+	lang.Default = &lang.Dict{
+		Global:  lang.Words{"is invalid": "无效"},
+		Widgets: map[string]lang.Words{"model.tests.validate": {"{{input}} is taken": "{{input}} 已被使用"}},
+	}
+
+	errs := mod.Validate(maps.MapStrAny{"email": "foo@test.com", "code": "bad"})
+	assert.Len(t, errs, 2)
+	for _, err := range errs {
+		switch err.Column {
+		case "email":
+			assert.Equal(t, []string{"foo@test.com 已被使用"}, err.Messages)
+		case "code":
+			assert.Equal(t, []string{"code 无效"}, err.Messages)
+		}
+	}
+}
+
+func TestValidateColumnContext(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.validate")
+	process.Register("tests.validate.code", func(p *process.Process) interface{} {
+		return p.Sid == "foo"
+	})
+
+	// the validators run with the session of the caller
+	column := mod.Columns["code"]
+	ok, _ := column.Validate("bar", maps.MapStrAny{}, mod.WithSID("foo"))
+	assert.True(t, ok)
+	ok, _ = column.Validate("bar", maps.MapStrAny{})
+	assert.False(t, ok)
+	assert.Len(t, mod.WithSID("foo").Validate(maps.MapStrAny{"code": "bar"}), 0)
+}
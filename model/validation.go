@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net/mail"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/yaoapp/kun/any"
@@ -11,8 +14,11 @@ import (
 	"github.com/yaoapp/kun/str"
 )
 
+// ValidationFunc 数据校验函数, row 为输入的整行数据
+type ValidationFunc func(value interface{}, row maps.MapStrAny, args ...interface{}) bool
+
 // Validations 数据校验函数
-var Validations = map[string]func(value interface{}, row maps.MapStrAny, args ...interface{}) bool{
+var Validations = map[string]ValidationFunc{
 	"typof":     ValidationTypeof,    // 校验数值类型 string, integer, float, number, datetime, timestamp,
 	"min":       ValidationMin,       // 最小值
 	"max":       ValidationMax,       // 最大值
@@ -22,6 +28,36 @@ var Validations = map[string]func(value interface{}, row maps.MapStrAny, args ..
 	"maxLength": ValidationMaxLength, // 最大长度
 	"email":     ValidationEmail,     // 邮箱地址
 	"mobile":    ValidationMobile,    // 手机号
+	"gt":        ValidationGt,        // 大于指定字段
+	"ge":        ValidationGe,        // 大于等于指定字段
+	"lt":        ValidationLt,        // 小于指定字段
+	"le":        ValidationLe,        // 小于等于指定字段
+	"eq":        ValidationEq,        // 等于指定字段
+	"ne":        ValidationNe,        // 不等于指定字段
+}
+
+var validationsMutex sync.RWMutex
+
+// RegisterValidation 注册数据校验函数, 同名函数将被覆盖 (运行时注册须使用该方法, 不可直接修改 Validations)
+func RegisterValidation(name string, fn ValidationFunc) {
+	validationsMutex.Lock()
+	defer validationsMutex.Unlock()
+	Validations[name] = fn
+}
+
+// UnregisterValidation 注销数据校验函数
+func UnregisterValidation(name string) {
+	validationsMutex.Lock()
+	defer validationsMutex.Unlock()
+	delete(Validations, name)
+}
+
+// validationOf 读取数据校验函数
+func validationOf(name string) (ValidationFunc, bool) {
+	validationsMutex.RLock()
+	defer validationsMutex.RUnlock()
+	fn, has := Validations[name]
+	return fn, has
 }
 
 // ValidationTypeof 校验数值类型
@@ -174,3 +210,105 @@ func ValidationMobile(value interface{}, _ maps.MapStrAny, args ...interface{})
 	}
 	return reg.MatchString(v.String())
 }
+
+// ValidationGt 大于指定字段 args[0] 为字段名称, 如 end_date > start_date
+func ValidationGt(value interface{}, row maps.MapStrAny, args ...interface{}) bool {
+	return validationCompare(value, row, args, func(res int) bool { return res > 0 })
+}
+
+// ValidationGe 大于等于指定字段
+func ValidationGe(value interface{}, row maps.MapStrAny, args ...interface{}) bool {
+	return validationCompare(value, row, args, func(res int) bool { return res >= 0 })
+}
+
+// ValidationLt 小于指定字段
+func ValidationLt(value interface{}, row maps.MapStrAny, args ...interface{}) bool {
+	return validationCompare(value, row, args, func(res int) bool { return res < 0 })
+}
+
+// ValidationLe 小于等于指定字段
+func ValidationLe(value interface{}, row maps.MapStrAny, args ...interface{}) bool {
+	return validationCompare(value, row, args, func(res int) bool { return res <= 0 })
+}
+
+// ValidationEq 等于指定字段 (如确认密码)
+func ValidationEq(value interface{}, row maps.MapStrAny, args ...interface{}) bool {
+	return validationCompare(value, row, args, func(res int) bool { return res == 0 })
+}
+
+// ValidationNe 不等于指定字段
+func ValidationNe(value interface{}, row maps.MapStrAny, args ...interface{}) bool {
+	return validationCompare(value, row, args, func(res int) bool { return res != 0 })
+}
+
+// validationCompare 与指定字段比较, 未提供该字段时 (如部分更新) 跳过校验
+func validationCompare(value interface{}, row maps.MapStrAny, args []interface{}, check func(res int) bool) bool {
+	if len(args) < 1 {
+		return true
+	}
+
+	other, has := row[fmt.Sprintf("%v", args[0])]
+	if !has || other == nil {
+		return true
+	}
+	return check(compareValues(value, other))
+}
+
+// compareTimeFormats the date formats of the compared strings
+var compareTimeFormats = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999", "2006-01-02 15:04:05", "2006-01-02"}
+
+// compareValues 比较数值, 数字 (含数字字符串) 按数值比较, 时间 (含日期字符串) 按时间比较, 其他按字符串比较
+func compareValues(a, b interface{}) int {
+	fa, oka := compareNumber(a)
+	fb, okb := compareNumber(b)
+	if oka && okb {
+		switch {
+		case fa > fb:
+			return 1
+		case fa < fb:
+			return -1
+		}
+		return 0
+	}
+
+	ta, oka := compareTime(a)
+	tb, okb := compareTime(b)
+	if oka && okb {
+		switch {
+		case ta.After(tb):
+			return 1
+		case ta.Before(tb):
+			return -1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+}
+
+// compareNumber the number or the numeric string
+func compareNumber(value interface{}) (float64, bool) {
+	if v := any.Of(value); v.IsNumber() {
+		return v.CFloat(), true
+	}
+
+	if s, ok := value.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// compareTime the time or the date string
+func compareTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, format := range compareTimeFormats {
+			if t, err := time.ParseInLocation(format, strings.TrimSpace(v), time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
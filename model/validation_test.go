@@ -2,8 +2,11 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
 )
 
 func TestValidationTypeof(t *testing.T) {
@@ -110,3 +113,42 @@ func TestValidationMobile(t *testing.T) {
 	assert.False(t, ValidationMobile("xiang", nil))
 	assert.False(t, ValidationMobile(1, nil))
 }
+
+func TestValidationCompare(t *testing.T) {
+	row := maps.MapStrAny{"start_date": "2022-01-01", "min": 10}
+	assert.True(t, ValidationGt("2022-01-02", row, "start_date"))
+	assert.False(t, ValidationGt("2022-01-01", row, "start_date"))
+	assert.True(t, ValidationGe("2022-01-01", row, "start_date"))
+	assert.True(t, ValidationLt(9.5, row, "min"))
+	assert.False(t, ValidationLe(11, row, "min"))
+	assert.True(t, ValidationEq(10.0, row, "min"))
+	assert.True(t, ValidationNe(11, row, "min"))
+
+	// the numeric strings and the date strings
+	assert.True(t, ValidationGt("10", maps.MapStrAny{"min": "9"}, "min"))
+	assert.True(t, ValidationLt(9, maps.MapStrAny{"min": "10.5"}, "min"))
+	assert.True(t, ValidationGt("2022-01-01 08:00:00", row, "start_date"))
+	assert.True(t, ValidationEq(time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local), row, "start_date"))
+
+	// the rule is skipped if the column is not given
+	assert.True(t, ValidationGt("2021-01-01", row, "end_date"))
+}
+
+func TestRegisterValidation(t *testing.T) {
+	RegisterValidation("tests.even", func(value interface{}, _ maps.MapStrAny, _ ...interface{}) bool {
+		return any.Of(value).CInt()%2 == 0
+	})
+	defer UnregisterValidation("tests.even")
+
+	column := &Column{Name: "score", Validations: []Validation{{Method: "tests.even", Message: "{{input}} is odd"}}}
+	ok, messages := column.Validate(3, maps.MapStrAny{})
+	assert.False(t, ok)
+	assert.Equal(t, []string{"3 is odd"}, messages)
+
+	ok, _ = column.Validate(4, maps.MapStrAny{})
+	assert.True(t, ok)
+
+	UnregisterValidation("tests.even")
+	_, has := validationOf("tests.even")
+	assert.False(t, has)
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/dbal"
+)
+
+// VersionColumn the version column of the models with the Version option (optimistic locking).
+// The version is checked only if the updated row takes it, the row without the version is updated
+// unconditionally (last write wins) and the version is still incremented.
+const VersionColumn = "__version"
+
+// versionOf pick the version of the given row, return the version (nil if the model does not enable
+// the version option or the row does not take a version) and a copy of the row which increments the version.
+// the given row is not changed
+func (mod *Model) versionOf(row maps.MapStrAny) (interface{}, maps.MapStrAny) {
+	if !mod.MetaData.Option.Version {
+		return nil, row
+	}
+
+	values := maps.MapStrAny{}
+	for name, value := range row {
+		values[name] = value
+	}
+	values.Set(VersionColumn, dbal.Raw(VersionColumn+" + 1"))
+	return row.Get(VersionColumn), values
+}
+
+// versionReset remove the given version of the created row, the version starts at 1
+func (mod *Model) versionReset(row maps.MapStrAny) {
+	if mod.MetaData.Option.Version {
+		row.Del(VersionColumn)
+	}
+}
+
+// versionConflict throw a 409 exception if the row exists but the version is stale
+func (mod *Model) versionConflict(id interface{}, version interface{}) {
+	if version == nil {
+		return
+	}
+
+	qb := mod.newQuery().Table(mod.MetaData.Table.Name).Where(mod.PrimaryKey, id)
+	mod.tenantWhere(qb)
+	cnt, err := qb.Count()
+	if err == nil && cnt > 0 {
+		exception.New("数据已被修改, 请刷新后重试 (%s: %v, %s: %v)", 409, mod.PrimaryKey, id, VersionColumn, version).Throw()
+	}
+}
+
+// versionConflictWhere throw a 409 exception if the rows exist but the version is stale
+func (mod *Model) versionConflictWhere(param QueryParam, version interface{}) {
+	param.Select = []interface{}{mod.PrimaryKey}
+	param.Withs = nil
+	param.Limit = 1
+	rows, err := mod.Get(param)
+	if err == nil && len(rows) > 0 {
+		exception.New("数据已被修改, 请刷新后重试 (%s: %v)", 409, VersionColumn, version).Throw()
+	}
+}
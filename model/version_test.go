@@ -0,0 +1,91 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestVersionUpdate(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.version")
+
+	id := mod.MustCreate(maps.MapStrAny{"name": "foo", VersionColumn: 10})
+	row := mod.MustFind(id, QueryParam{})
+	assert.Equal(t, 1, any.Of(row.Get(VersionColumn)).CInt())
+
+	// update with the current version
+	err := mod.Update(id, maps.MapStrAny{"name": "bar", VersionColumn: 1})
+	assert.Nil(t, err)
+	row = mod.MustFind(id, QueryParam{})
+	assert.Equal(t, "bar", row.Get("name"))
+	assert.Equal(t, 2, any.Of(row.Get(VersionColumn)).CInt())
+
+	// update with a stale version
+	assert.Equal(t, 409, exceptionCode(func() { mod.Update(id, maps.MapStrAny{"name": "baz", VersionColumn: 1}) }))
+	assert.Equal(t, "bar", mod.MustFind(id, QueryParam{}).Get("name"))
+
+	// update without the version, the row is updated unconditionally and the version is incremented
+	err = mod.Update(id, maps.MapStrAny{"name": "baz"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, any.Of(mod.MustFind(id, QueryParam{}).Get(VersionColumn)).CInt())
+
+	// the given row is not changed
+	row = maps.MapStrAny{"name": "qux", VersionColumn: 3}
+	assert.Nil(t, mod.Update(id, row))
+	assert.Equal(t, 3, row.Get(VersionColumn))
+
+	// the row does not exist
+	assert.NotNil(t, mod.Update(9999, maps.MapStrAny{"name": "baz", VersionColumn: 1}))
+}
+
+func TestVersionSave(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.version")
+
+	id := mod.MustSave(maps.MapStrAny{"name": "foo"})
+	mod.MustSave(maps.MapStrAny{"id": id, "name": "bar", VersionColumn: 1})
+	assert.Equal(t, 2, any.Of(mod.MustFind(id, QueryParam{}).Get(VersionColumn)).CInt())
+
+	assert.Equal(t, 409, exceptionCode(func() { mod.MustSave(maps.MapStrAny{"id": id, "name": "baz", VersionColumn: 1}) }))
+
+	mod.MustUpdateWhere(QueryParam{}, maps.MapStrAny{"name": "all"})
+	assert.Equal(t, 3, any.Of(mod.MustFind(id, QueryParam{}).Get(VersionColumn)).CInt())
+}
+
+func TestVersionUpdateWhere(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	mod := prepareTests(t, "tests.version")
+	id := mod.MustCreate(maps.MapStrAny{"name": "foo"})
+	param := QueryParam{Wheres: []QueryWhere{{Column: "id", Value: id}}}
+
+	effect := mod.MustUpdateWhere(param, maps.MapStrAny{"name": "bar", VersionColumn: 1})
+	assert.Equal(t, 1, effect)
+	assert.Equal(t, 2, any.Of(mod.MustFind(id, QueryParam{}).Get(VersionColumn)).CInt())
+
+	// the version is stale
+	assert.Equal(t, 409, exceptionCode(func() { mod.MustUpdateWhere(param, maps.MapStrAny{"name": "baz", VersionColumn: 1}) }))
+	assert.Equal(t, "bar", mod.MustFind(id, QueryParam{}).Get("name"))
+
+	// the upsert could not check the version
+	_, err := mod.Upsert([]string{"id", "name"}, [][]interface{}{{id, "baz"}}, []string{"id"}, nil)
+	assert.NotNil(t, err)
+}
+
+func exceptionCode(fn func()) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err, ok := r.(exception.Exception); ok {
+				code = err.Code
+			}
+		}
+	}()
+	fn()
+	return 0
+}
@@ -0,0 +1,181 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/exception"
+	"github.com/yaoapp/kun/maps"
+	"github.com/yaoapp/xun/dbal"
+)
+
+// validateVirtual check the virtual columns, either the expression or the process should be given
+func (mod *Model) validateVirtual() error {
+	for _, column := range mod.MetaData.Columns {
+		if column.Virtual == nil {
+			continue
+		}
+
+		if (column.Virtual.Expression == "") == (column.Virtual.Process == "") {
+			return fmt.Errorf("the virtual column %s should have either an expression or a process", column.Name)
+		}
+
+		if column.Primary || column.Unique || column.Index || column.Crypt != "" {
+			return fmt.Errorf("the virtual column %s can not be indexed or encrypted", column.Name)
+		}
+	}
+	return nil
+}
+
+// virtualSelect the select expression of the virtual column, the process columns are filled after fetching
+func (column *Column) virtualSelect(mod *Model, alias string, varName string) interface{} {
+	if column.Virtual.Expression != "" {
+		return dbal.Raw("(" + virtualQualify(mod, alias, column.Virtual.Expression) + ") as " + varName)
+	}
+	return dbal.Raw("NULL as " + varName)
+}
+
+// virtualExpression the SQL expression of the virtual column, only the SQL expression columns can be used in the query conditions
+func (column *Column) virtualExpression(mod *Model, alias string) string {
+	if column.Virtual.Expression == "" {
+		exception.New("the virtual column %s can not be used in the query conditions", 400, column.Name).Throw()
+	}
+	return "(" + virtualQualify(mod, alias, column.Virtual.Expression) + ")"
+}
+
+// virtualQualify prefix the column references of the expression with the alias, the expression of a joined model should not refer to the columns of the main table.
+// eg: score * 2 => user__rel__owner.score * 2
+// The quoted strings, the qualified names and the function names are kept.
+func virtualQualify(mod *Model, alias string, expression string) string {
+	if alias == "" || mod == nil {
+		return expression
+	}
+
+	runes := []rune(expression)
+	res := strings.Builder{}
+	for i := 0; i < len(runes); {
+		c := runes[i]
+
+		// 字符串或带引号的标识符
+		if c == '\'' || c == '"' || c == '`' {
+			j := i + 1
+			for j < len(runes) && runes[j] != c {
+				j++
+			}
+			if j < len(runes) {
+				j++
+			}
+			res.WriteString(string(runes[i:j]))
+			i = j
+			continue
+		}
+
+		if !virtualIdent(c) {
+			res.WriteRune(c)
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && virtualIdent(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+
+		next := j
+		for next < len(runes) && unicode.IsSpace(runes[next]) {
+			next++
+		}
+
+		qualified := i > 0 && runes[i-1] == '.'
+		called := next < len(runes) && (runes[next] == '(' || runes[next] == '.')
+		column, has := mod.Columns[word]
+		if has && column.Virtual == nil && !qualified && !called && !unicode.IsDigit(c) {
+			res.WriteString(alias + "." + word)
+		} else {
+			res.WriteString(word)
+		}
+		i = j
+	}
+	return res.String()
+}
+
+func virtualIdent(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// virtualSelected check if the SQL expression virtual columns are selected
+func (mod *Model) virtualSelected(columns []interface{}) bool {
+	for _, col := range columns {
+		name, ok := col.(string)
+		if !ok {
+			continue
+		}
+		if column, has := mod.Columns[name]; has && column.Virtual != nil && column.Virtual.Expression != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// virtualDrop remove the virtual columns and their values from the bulk writes, the rows with a wrong length are kept for the validation
+func (mod *Model) virtualDrop(columns []string, rows [][]interface{}) ([]string, [][]interface{}) {
+	keep := []int{}
+	for cid, name := range columns {
+		if column, has := mod.Columns[name]; !has || column.Virtual == nil {
+			keep = append(keep, cid)
+		}
+	}
+
+	if len(keep) == len(columns) {
+		return columns, rows
+	}
+
+	res := []string{}
+	for _, cid := range keep {
+		res = append(res, columns[cid])
+	}
+
+	values := make([][]interface{}, len(rows))
+	for rid, row := range rows {
+		if len(row) != len(columns) {
+			values[rid] = row
+			continue
+		}
+		values[rid] = []interface{}{}
+		for _, cid := range keep {
+			values[rid] = append(values[rid], row[cid])
+		}
+	}
+	return res, values
+}
+
+// computeRow fill the process virtual columns of the fetched row, the process args is the row of the column's model
+func computeRow(row maps.MapStr, cmap map[string]ColumnMap) {
+	for _, col := range cmap {
+		if col.Column.Virtual == nil || col.Column.Virtual.Process == "" {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(col.Export, col.Column.Name)
+		data := maps.MapStr{}
+		for key, value := range row {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			name := strings.TrimPrefix(key, prefix)
+			if name != col.Column.Name && !strings.Contains(name, ".") {
+				data[name] = value
+			}
+		}
+
+		p := process.New(col.Column.Virtual.Process, data)
+		if col.Model != nil {
+			col.Model.hook(p)
+		}
+		row[col.Export] = p.Run()
+	}
+}
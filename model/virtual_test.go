@@ -0,0 +1,129 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/process"
+	"github.com/yaoapp/kun/any"
+	"github.com/yaoapp/kun/maps"
+)
+
+func TestVirtualColumns(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	process.Register("tests.virtual.label", processVirtualLabel)
+	mod := seedTests(t, "tests.virtual", 4)
+
+	// the virtual columns are not written
+	id := mod.MustCreate(maps.MapStrAny{"name": "foo", "score": 5, "double": 100, "label": "ignored"})
+	row := mod.MustFind(id, QueryParam{})
+	assert.Equal(t, 10, any.Of(row.Get("double")).CInt())
+	assert.Equal(t, "foo:5", row.Get("label"))
+
+	// the expression columns can be filtered and ordered
+	rows := mod.MustGet(QueryParam{
+		Select: []interface{}{"name", "score", "double", "label"},
+		Wheres: []QueryWhere{{Column: "double", OP: "ge", Value: 6}},
+		Orders: []QueryOrder{{Column: "double", Option: "desc"}},
+	})
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "foo", rows[0].Get("name"))
+	assert.Equal(t, "user-3:3", rows[1].Get("label"))
+
+	// the process columns can not be filtered
+	assert.Panics(t, func() {
+		mod.MustGet(QueryParam{Wheres: []QueryWhere{{Column: "label", Value: "foo:5"}}})
+	})
+
+	// the virtual columns are not created
+	blueprint, err := mod.Blueprint()
+	assert.Nil(t, err)
+	names := []string{}
+	for _, column := range blueprint.Columns {
+		names = append(names, column.Name)
+	}
+	assert.NotContains(t, names, "double")
+	assert.NotContains(t, names, "label")
+}
+
+func TestVirtualInsert(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	process.Register("tests.virtual.label", processVirtualLabel)
+	mod := seedTests(t, "tests.virtual", 4)
+
+	// the virtual columns are dropped from the bulk writes
+	mod.MustInsert([]string{"name", "score", "double"}, [][]interface{}{{"foo", 10, 1}, {"bar", 20, 2}})
+	_, err := mod.Upsert([]string{"id", "name", "score", "label"}, [][]interface{}{{1, "baz", 30, "ignored"}}, []string{"id"}, nil)
+	assert.Nil(t, err)
+
+	rows := mod.MustGet(QueryParam{
+		Select: []interface{}{"id", "name", "double"},
+		Wheres: []QueryWhere{{Column: "name", OP: "in", Value: []string{"foo", "bar", "baz"}}},
+		Orders: []QueryOrder{{Column: "id"}},
+	})
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "baz", rows[0].Get("name"))
+	assert.Equal(t, 60, any.Of(rows[0].Get("double")).CInt())
+	assert.Equal(t, 20, any.Of(rows[1].Get("double")).CInt())
+	assert.Equal(t, 40, any.Of(rows[2].Get("double")).CInt())
+}
+
+func TestVirtualJoin(t *testing.T) {
+	dbconnect(t)
+	defer clean()
+	process.Register("tests.virtual.label", processVirtualLabel)
+	mod := seedTests(t, "tests.virtual", 4)
+	entry := prepareTests(t, "tests.virtual.entry")
+	owner := mod.MustCreate(maps.MapStrAny{"name": "foo", "score": 5})
+	entry.MustInsert([]string{"score", "owner_id"}, [][]interface{}{{100, owner}})
+
+	// the expression of the joined model refers to the columns of its own table
+	rows := entry.MustGet(QueryParam{
+		Select: []interface{}{"id", "score"},
+		Withs:  map[string]With{"owner": {Query: QueryParam{Select: []interface{}{"id", "double"}}}},
+	})
+	assert.Len(t, rows, 1)
+	assert.Equal(t, 10, any.Of(maps.Of(rows[0]).Dot().Get("owner.double")).CInt())
+
+	// the joined sub query
+	rows = entry.MustGet(QueryParam{
+		Select: []interface{}{"id", "score"},
+		Withs: map[string]With{"owner": {Query: QueryParam{
+			Select: []interface{}{"id", "double"},
+			Wheres: []QueryWhere{{Column: "double", OP: "ge", Value: 10}},
+		}}},
+	})
+	assert.Len(t, rows, 1)
+	assert.Equal(t, 10, any.Of(maps.Of(rows[0]).Dot().Get("owner.double")).CInt())
+}
+
+func TestVirtualQualify(t *testing.T) {
+	mod := &Model{Columns: map[string]*Column{
+		"score":  {Name: "score"},
+		"name":   {Name: "name"},
+		"double": {Name: "double", Virtual: &VirtualColumn{Expression: "score * 2"}},
+	}}
+	assert.Equal(t, "score * 2", virtualQualify(mod, "", "score * 2"))
+	assert.Equal(t, "t1.score * 2", virtualQualify(mod, "t1", "score * 2"))
+	assert.Equal(t, "CONCAT(t1.name, 'score', t2.score, `name`)", virtualQualify(mod, "t1", "CONCAT(name, 'score', t2.score, `name`)"))
+	assert.Equal(t, "score(1) + double", virtualQualify(mod, "t1", "score(1) + double"))
+}
+
+func TestVirtualInvalid(t *testing.T) {
+	_, err := LoadSource([]byte(`{
+		"table": { "name": "tests_virtual_invalid" },
+		"columns": [
+			{ "name": "id", "type": "ID" },
+			{ "name": "double", "type": "integer", "virtual": {} }
+		]
+	}`), "virtual_invalid.mod.json", "tests.virtual_invalid")
+	assert.NotNil(t, err)
+}
+
+func processVirtualLabel(p *process.Process) interface{} {
+	row := p.ArgsMap(0)
+	return fmt.Sprintf("%v:%v", row["name"], row["score"])
+}
@@ -10,6 +10,12 @@ import (
 // Handlers ProcessHanlders
 var Handlers = map[string]Handler{}
 
+// RequestIDKey the name of the request id in the global vars
+var RequestIDKey = "__request_id"
+
+// RootKey the name of the root privileges flag in the global vars, it is set by the root scripts only
+var RootKey = "__root"
+
 // New make a new process
 func New(name string, args ...interface{}) *Process {
 	process, err := Of(name, args...)
@@ -80,12 +86,59 @@ func (process *Process) WithSID(sid string) *Process {
 	return process
 }
 
-// WithGlobal set the global vars
+// WithGlobal set the global vars, the root privileges flag is removed (it is set by WithRoot only)
 func (process *Process) WithGlobal(global map[string]interface{}) *Process {
+	if _, has := global[RootKey]; has {
+		copied := map[string]interface{}{}
+		for key, value := range global {
+			if key != RootKey {
+				copied[key] = value
+			}
+		}
+		global = copied
+	}
+	process.Global = global
+	return process
+}
+
+// WithRequestID set the request id, the global vars are copied (they could be shared by the other processes) and passed to the sub processes
+func (process *Process) WithRequestID(id string) *Process {
+	global := map[string]interface{}{}
+	for key, value := range process.Global {
+		global[key] = value
+	}
+	global[RequestIDKey] = id
+	process.Global = global
+	return process
+}
+
+// RequestID get the request id
+func (process *Process) RequestID() string {
+	id, _ := process.Global[RequestIDKey].(string)
+	return id
+}
+
+// WithRoot set or clear the root privileges flag, the global vars are copied and passed to the sub processes
+func (process *Process) WithRoot(root bool) *Process {
+	global := map[string]interface{}{}
+	for key, value := range process.Global {
+		global[key] = value
+	}
+
+	delete(global, RootKey)
+	if root {
+		global[RootKey] = true
+	}
 	process.Global = global
 	return process
 }
 
+// Root check if the process has the root privileges
+func (process *Process) Root() bool {
+	root, _ := process.Global[RootKey].(bool)
+	return root
+}
+
 // handler get the process handler
 func (process *Process) handler() (Handler, error) {
 	if hander, has := Handlers[process.Handler]; has {
@@ -240,6 +240,35 @@ func TestWithGlobal(t *testing.T) {
 	assert.Equal(t, map[string]interface{}{"hello": "world"}, data["global"])
 }
 
+func TestWithRoot(t *testing.T) {
+	prepare(t)
+	global := map[string]interface{}{"hello": "world"}
+
+	p := New("unit.test.prepare").WithGlobal(global).WithRoot(true)
+	assert.True(t, p.Root())
+	assert.Equal(t, "world", p.Global["hello"])
+	assert.Nil(t, global[RootKey])
+
+	p = New("unit.test.prepare").WithGlobal(p.Global).WithRoot(false)
+	assert.False(t, p.Root())
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, p.Global)
+
+	// the root privileges could not be set by the global vars
+	p = New("unit.test.prepare").WithGlobal(map[string]interface{}{"hello": "world", RootKey: true})
+	assert.False(t, p.Root())
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, p.Global)
+}
+
+func TestWithRequestID(t *testing.T) {
+	prepare(t)
+	global := map[string]interface{}{"hello": "world"}
+
+	p := New("unit.test.prepare").WithGlobal(global).WithRequestID("req-0001")
+	assert.Equal(t, "req-0001", p.RequestID())
+	assert.Equal(t, "world", p.Global["hello"])
+	assert.Nil(t, global[RequestIDKey])
+}
+
 func prepare(t *testing.T) {
 	Register("unit.test.prepare", processTest)
 	Register("flows", processTest)
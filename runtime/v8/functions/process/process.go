@@ -39,6 +39,7 @@ func exec(info *v8go.FunctionCallbackInfo) *v8go.Value {
 
 	goRes, err := process.New(jsArgs[0].String(), goArgs...).
 		WithGlobal(global).
+		WithRoot(false).
 		WithSID(sid).
 		Exec()
 
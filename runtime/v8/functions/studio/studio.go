@@ -43,6 +43,7 @@ func exec(info *v8go.FunctionCallbackInfo) *v8go.Value {
 
 	goRes, err := process.New(jsArgs[0].String(), goArgs...).
 		WithGlobal(global).
+		WithRoot(true).
 		WithSID(sid).
 		Exec()
 
@@ -0,0 +1,106 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/yaoapp/gou/model"
+	"github.com/yaoapp/gou/runtime/v8/bridge"
+	"rogchap.com/v8go"
+)
+
+// ExportFunction function template
+// Transaction(fn, connector?) run the function in a database transaction, roll back if it throws an exception
+func ExportFunction(iso *v8go.Isolate) *v8go.FunctionTemplate {
+	return v8go.NewFunctionTemplate(iso, exec)
+}
+
+// exec
+func exec(info *v8go.FunctionCallbackInfo) *v8go.Value {
+
+	jsArgs := info.Args()
+	if len(jsArgs) < 1 {
+		return bridge.JsException(info.Context(), "missing parameters")
+	}
+
+	if !jsArgs[0].IsFunction() {
+		return bridge.JsException(info.Context(), "the first parameter should be a function")
+	}
+
+	connector := ""
+	if len(jsArgs) > 1 {
+		if !jsArgs[1].IsString() {
+			return bridge.JsException(info.Context(), "the second parameter should be a string")
+		}
+		connector = jsArgs[1].String()
+	}
+
+	fn, err := jsArgs[0].AsFunction()
+	if err != nil {
+		return bridge.JsException(info.Context(), err)
+	}
+
+	_, global, _, v := bridge.ShareData(info.Context())
+	if v != nil {
+		return v
+	}
+
+	var jsRes *v8go.Value
+	run := func(global map[string]interface{}) error {
+		restore, err := setGlobal(info.Context(), global)
+		if err != nil {
+			return err
+		}
+		defer restore()
+
+		jsRes, err = fn.Call(v8go.Undefined(info.Context().Isolate()))
+		return err
+	}
+
+	// Already in the transaction of the connector
+	if tx := model.TransactionOf(global); tx != nil && tx.Connector == model.ConnectorName(connector) {
+		jsRes, err = fn.Call(v8go.Undefined(info.Context().Isolate()))
+	} else {
+		err = model.Transaction(connector, func(tx *model.Tx) error {
+			return run(tx.Global(global))
+		})
+	}
+
+	if err != nil {
+		return bridge.JsException(info.Context(), err)
+	}
+
+	if jsRes == nil {
+		return v8go.Undefined(info.Context().Isolate())
+	}
+	return jsRes
+}
+
+// setGlobal replace the global vars of the script, the processes called by the function run in the transaction
+func setGlobal(ctx *v8go.Context, global map[string]interface{}) (func(), error) {
+	jsData, err := ctx.Global().Get("__yao_data")
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsData.AsObject()
+	if err != nil {
+		return nil, fmt.Errorf("__yao_data is not an object")
+	}
+
+	prev, err := data.Get("DATA")
+	if err != nil {
+		return nil, err
+	}
+
+	jsGlobal, err := bridge.JsValue(ctx, global)
+	if err != nil {
+		return nil, err
+	}
+
+	err = data.Set("DATA", jsGlobal)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { data.Set("DATA", prev) }, nil
+}
@@ -0,0 +1,97 @@
+package transaction
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaoapp/gou/model"
+	"github.com/yaoapp/gou/runtime/v8/bridge"
+	processT "github.com/yaoapp/gou/runtime/v8/functions/process"
+	"github.com/yaoapp/xun/capsule"
+	"rogchap.com/v8go"
+)
+
+func TestTransaction(t *testing.T) {
+	mod := prepareModel(t)
+	ctx := prepare(t)
+	defer close(ctx)
+
+	jsRes, err := ctx.RunScript(`
+		const test = () => {
+			return Transaction(() => {
+				const id = Process("models.tests.jstx.Create", { name: "foo" });
+				const row = Process("models.tests.jstx.Find", id, {});
+				return row.name;
+			});
+		}
+		test()
+	`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := bridge.GoValue(jsRes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "foo", res)
+	assert.Len(t, mod.MustGet(model.QueryParam{}), 1)
+
+	_, err = ctx.RunScript(`
+		Transaction(() => {
+			Process("models.tests.jstx.Create", { name: "bar" });
+			throw new Error("abort");
+		})
+	`, "")
+	assert.NotNil(t, err)
+	assert.Len(t, mod.MustGet(model.QueryParam{}), 1)
+}
+
+func close(ctx *v8go.Context) {
+	ctx.Isolate().Dispose()
+}
+
+func prepare(t *testing.T) *v8go.Context {
+
+	iso := v8go.NewIsolate()
+
+	template := v8go.NewObjectTemplate(iso)
+	template.Set("Transaction", ExportFunction(iso))
+	template.Set("Process", processT.ExportFunction(iso))
+
+	ctx := v8go.NewContext(iso, template)
+	jsData, err := bridge.JsValue(ctx, map[string]interface{}{"SID": "", "ROOT": false, "DATA": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ctx.Global().Set("__yao_data", jsData); err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+func prepareModel(t *testing.T) *model.Model {
+
+	switch os.Getenv("GOU_TEST_DB_DRIVER") {
+	case "sqlite3":
+		capsule.AddConn("primary", "sqlite3", os.Getenv("GOU_TEST_DSN")).SetAsGlobal()
+	default:
+		capsule.AddConn("primary", "mysql", os.Getenv("GOU_TEST_DSN")).SetAsGlobal()
+	}
+
+	mod, err := model.LoadSource([]byte(`{
+		"table": { "name": "tests_jstx" },
+		"columns": [{ "name": "id", "type": "ID" }, { "name": "name", "type": "string" }]
+	}`), "jstx.mod.json", "tests.jstx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mod.Migrate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mod
+}
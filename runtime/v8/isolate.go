@@ -8,6 +8,7 @@ import (
 	langT "github.com/yaoapp/gou/runtime/v8/functions/lang"
 	processT "github.com/yaoapp/gou/runtime/v8/functions/process"
 	studioT "github.com/yaoapp/gou/runtime/v8/functions/studio"
+	transactionT "github.com/yaoapp/gou/runtime/v8/functions/transaction"
 	exceptionT "github.com/yaoapp/gou/runtime/v8/objects/exception"
 	fsT "github.com/yaoapp/gou/runtime/v8/objects/fs"
 	httpT "github.com/yaoapp/gou/runtime/v8/objects/http"
@@ -57,6 +58,7 @@ func newIsolate() *Isolate {
 	template.Set("$L", langT.ExportFunction(iso))
 	template.Set("Process", processT.ExportFunction(iso))
 	template.Set("Studio", studioT.ExportFunction(iso))
+	template.Set("Transaction", transactionT.ExportFunction(iso))
 
 	new := &Isolate{
 		Isolate:  iso,
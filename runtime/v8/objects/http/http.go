@@ -7,6 +7,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/yaoapp/gou/cast"
 	"github.com/yaoapp/gou/http"
+	"github.com/yaoapp/gou/process"
 	"github.com/yaoapp/gou/runtime/v8/bridge"
 	"rogchap.com/v8go"
 )
@@ -374,5 +375,12 @@ func (obj *Object) new(info *v8go.FunctionCallbackInfo, idx, from int) (*http.Re
 		req.WithHeader(headers)
 	}
 
+	// Forward the request id
+	if _, global, _, jsErr := bridge.ShareData(info.Context()); jsErr == nil && !req.HasHeader(http.RequestIDHeader) {
+		if id, ok := global[process.RequestIDKey].(string); ok && id != "" {
+			req.SetHeader(http.RequestIDHeader, id)
+		}
+	}
+
 	return req, nil
 }
@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"github.com/yaoapp/gou/connector"
+	"github.com/yaoapp/gou/connector/database"
 	"github.com/yaoapp/gou/schema/types"
 	"github.com/yaoapp/gou/schema/xun"
 	"github.com/yaoapp/xun/capsule"
@@ -15,7 +17,19 @@ func Use(name string) types.Schema {
 	switch name {
 	case "tests":
 		return &xun.Xun{}
+	case "", "default":
+		return &xun.Xun{
+			Option: xun.Option{Manager: capsule.Global},
+		}
 	default:
+		// the database connector
+		if c, err := connector.Select(name); err == nil {
+			if db, ok := c.(*database.Xun); ok {
+				return &xun.Xun{
+					Option: xun.Option{Manager: db.Manager},
+				}
+			}
+		}
 		return &xun.Xun{
 			Option: xun.Option{Manager: capsule.Global},
 		}
@@ -30,6 +30,17 @@ func (cache *Cache) Set(key string, value interface{}, ttl time.Duration) error
 	return nil
 }
 
+// Claim adds a value to the cache only if the key does not exist.
+func (cache *Cache) Claim(key string, value interface{}, ttl time.Duration) (bool, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.lru.Contains(key) {
+		return false, nil
+	}
+	cache.lru.Add(key, value)
+	return true, nil
+}
+
 // Del remove is used to purge a key from the cache
 func (cache *Cache) Del(key string) error {
 	cache.lru.Remove(key)
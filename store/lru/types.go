@@ -1,9 +1,14 @@
 package lru
 
-import lru "github.com/hashicorp/golang-lru"
+import (
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+)
 
 // Cache lru cache
 type Cache struct {
-	size int
-	lru  *lru.ARCCache
+	size  int
+	lru   *lru.ARCCache
+	mutex sync.Mutex
 }
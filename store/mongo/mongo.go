@@ -67,6 +67,21 @@ func (store *Store) Set(key string, value interface{}, ttl time.Duration) error
 	return nil
 }
 
+// Claim adds a value to the store only if the key does not exist (the key is unique indexed).
+func (store *Store) Claim(key string, value interface{}, ttl time.Duration) (bool, error) {
+	doc := bson.D{{Key: "key", Value: key}, {Key: "value", Value: value}}
+	_, err := store.Collection.InsertOne(context.TODO(), doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		log.Error("Store mongo Claim %s: %s", key, err.Error())
+		return false, err
+	}
+	return true, nil
+}
+
 // Del remove is used to purge a key from the store
 func (store *Store) Del(key string) error {
 	filter := bson.D{{Key: "key", Value: key}}
@@ -59,6 +59,23 @@ func (store *Store) Set(key string, value interface{}, ttl time.Duration) error
 	return nil
 }
 
+// Claim adds a value to the store only if the key does not exist.
+func (store *Store) Claim(key string, value interface{}, ttl time.Duration) (bool, error) {
+	key = fmt.Sprintf("%s%s", store.Option.Prefix, key)
+	bytes, err := jsoniter.Marshal(value)
+	if err != nil {
+		log.Error("Store redis Claim %s: %s", key, err.Error())
+		return false, err
+	}
+
+	ok, err := store.rdb.SetNX(context.Background(), key, bytes, ttl).Result()
+	if err != nil {
+		log.Error("Store redis Claim %s: %s", key, err.Error())
+		return false, err
+	}
+	return ok, nil
+}
+
 // Del remove is used to purge a key from the store
 func (store *Store) Del(key string) error {
 	key = fmt.Sprintf("%s%s", store.Option.Prefix, key)
@@ -3,6 +3,8 @@ package store
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/yaoapp/gou/application"
 	"github.com/yaoapp/gou/connector"
@@ -54,6 +56,24 @@ func Load(file string, name string) (Store, error) {
 	return Pools[name], nil
 }
 
+// claimMutex serialize the claims of the stores which do not implement the Claimer interface
+var claimMutex sync.Mutex
+
+// Claim set the value only if the key does not exist, return false if the key exists.
+// The stores which do not implement the Claimer interface are checked and set with a process-local lock.
+func Claim(stor Store, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if claimer, ok := stor.(Claimer); ok {
+		return claimer.Claim(key, value, ttl)
+	}
+
+	claimMutex.Lock()
+	defer claimMutex.Unlock()
+	if stor.Has(key) {
+		return false, nil
+	}
+	return true, stor.Set(key, value, ttl)
+}
+
 // Select Select loaded kv store
 func Select(name string) Store {
 	store, has := Pools[name]
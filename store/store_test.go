@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yaoapp/gou/application"
@@ -20,18 +21,21 @@ func TestLRU(t *testing.T) {
 	lru := newStore(t, nil)
 	testBasic(t, lru)
 	testMulti(t, lru)
+	testClaim(t, lru)
 }
 
 func TestRedis(t *testing.T) {
 	redis := newStore(t, getConnector(t, "redis"))
 	testBasic(t, redis)
 	testMulti(t, redis)
+	testClaim(t, redis)
 }
 
 func TestMongo(t *testing.T) {
 	mongo := newStore(t, getConnector(t, "mongo"))
 	testBasic(t, mongo)
 	testMulti(t, mongo)
+	testClaim(t, mongo)
 }
 
 func testBasic(t *testing.T, kv Store) {
@@ -103,6 +107,27 @@ func testBasic(t *testing.T, kv Store) {
 
 }
 
+func testClaim(t *testing.T, kv Store) {
+	kv.Clear()
+	defer kv.Clear()
+
+	ok, err := Claim(kv, "key1", "foo", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = Claim(kv, "key1", "bar", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	value, _ := kv.Get("key1")
+	assert.Equal(t, "foo", value)
+
+	kv.Del("key1")
+	ok, err = Claim(kv, "key1", "bar", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
 func testMulti(t *testing.T, kv Store) {
 
 	kv.SetMulti(map[string]interface{}{"key1": "foo", "key2": 1024, "key3": 0.618}, 0)
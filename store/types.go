@@ -19,6 +19,12 @@ type Store interface {
 	GetSetMulti(keys []string, ttl time.Duration, getValue func(key string) (interface{}, error)) map[string]interface{}
 }
 
+// Claimer the store which sets the value only if the key does not exist,
+// the claim is atomic across the instances sharing the store (eg: redis, mongo)
+type Claimer interface {
+	Claim(key string, value interface{}, ttl time.Duration) (bool, error)
+}
+
 // Instance the kv-store setting
 type Instance struct {
 	Name        string                 `json:"name"`
@@ -8,4 +8,7 @@ type UploadFile struct {
 	TempFile string               `json:"tempFile"`
 	Size     int64                `json:"size"`
 	Header   textproto.MIMEHeader `json:"mimeType"`
+	Hash     string               `json:"hash,omitempty"`       // the sha256 of the content
+	FS       string               `json:"filesystem,omitempty"` // the filesystem name, if the file was streamed into a filesystem
+	Path     string               `json:"path,omitempty"`       // the file path in the filesystem
 }
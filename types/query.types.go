@@ -2,17 +2,19 @@ package types
 
 // QueryParam 数据查询器参数
 type QueryParam struct {
-	Model    string          `json:"model,omitempty"`
-	Table    string          `json:"table,omitempty"`
-	Alias    string          `json:"alias,omitempty"`
-	Export   string          `json:"export,omitempty"` // 导出前缀
-	Select   []interface{}   `json:"select,omitempty"` // string | dbal.Raw
-	Wheres   []QueryWhere    `json:"wheres,omitempty"`
-	Orders   []QueryOrder    `json:"orders,omitempty"`
-	Limit    int             `json:"limit,omitempty"`
-	Page     int             `json:"page,omitempty"`
-	PageSize int             `json:"pagesize,omitempty"`
-	Withs    map[string]With `json:"withs,omitempty"`
+	Model       string          `json:"model,omitempty"`
+	Table       string          `json:"table,omitempty"`
+	Alias       string          `json:"alias,omitempty"`
+	Export      string          `json:"export,omitempty"` // 导出前缀
+	Select      []interface{}   `json:"select,omitempty"` // string | dbal.Raw
+	Wheres      []QueryWhere    `json:"wheres,omitempty"`
+	Orders      []QueryOrder    `json:"orders,omitempty"`
+	Limit       int             `json:"limit,omitempty"`
+	Page        int             `json:"page,omitempty"`
+	PageSize    int             `json:"pagesize,omitempty"`
+	Withs       map[string]With `json:"withs,omitempty"`
+	WithTrashed bool            `json:"withTrashed,omitempty"` // 包含已删除数据 (软删除)
+	OnlyTrashed bool            `json:"onlyTrashed,omitempty"` // 仅查询已删除数据 (软删除)
 }
 
 // With relations 关联查询